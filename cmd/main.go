@@ -2,32 +2,83 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	_ "time/tzdata" // timezones of stores are loaded on any platform
 
 	"github.com/caarlos0/env/v8"
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/actions"
+	appsupload "github.com/Toshik1978/csv2adyen/pkg/commands/apps/upload"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/cellular"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/certificates"
+	certificatesupload "github.com/Toshik1978/csv2adyen/pkg/commands/certificates/upload"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/close"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/install"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/link"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/logos/download"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/logos/upload"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/method"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/offline"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/reassign"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/rollout/abort"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/sales"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/stores"
 	"github.com/Toshik1978/csv2adyen/pkg/commands/sweep"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/terminals"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/terminals/firmware"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/terminals/stale"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/terminalsettings/apply"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/terminalsettings/drift"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/terminalsettings/explain"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/terminalsettings/export"
+	"github.com/Toshik1978/csv2adyen/pkg/commands/uninstall"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/rollout"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
 )
 
 const (
 	version = "%s, built %s"
+
+	outputText = "text"
+	outputJSON = "json"
+
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+
+	logFileMaxSize    = 100 // megabytes
+	logFileMaxBackups = 10
+	logFileMaxAge     = 30 // days
+)
+
+// Exit codes.
+
+const (
+	exitOK             = 0
+	exitFailure        = 1
+	exitInvalidInput   = 2
+	exitConfig         = 3
+	exitPartialFailure = 4
+	exitTotalFailure   = 5
+	exitDrift          = 6
+	exitNonCompliant   = 7
+	exitInterrupted    = 130
 )
 
 var (
@@ -36,29 +87,109 @@ var (
 )
 
 func main() {
-	logger, err := newLogger()
+	client := newHTTPClient()
+	app := newApp(client)
+
+	ctx, stop := newSignalContext()
+	err := app.RunContext(ctx, os.Args)
+	stop()
+
 	if err != nil {
-		log.Fatal("Failed to create the logger", err)
+		log.Println("Failed to run the application: ", err)
 	}
-	client := newHTTPClient()
-	config := newConfig(logger)
-	app := newApp(logger, client, config)
+	os.Exit(exitCode(err))
+}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal("Failed to run the application: ", err)
+// commandError declare the error returned by the command, other errors come from CLI parser.
+type commandError struct {
+	err error
+}
+
+func (e *commandError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// exitCode maps the application error to the exit code.
+// Failed rows are checked first: the row, rejected by Adyen, doesn't make the whole run the configuration error.
+// Errors of commands, which are not classified (network, file I/O), are failures,
+// errors, which are not classified and don't come from commands, come from CLI parser (unknown or missing flags).
+func exitCode(err error) int {
+	var cmdErr *commandError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, commands.ErrInterrupted):
+		return exitInterrupted
+	case errors.Is(err, commands.ErrPartialFailure):
+		return exitPartialFailure
+	case errors.Is(err, commands.ErrTotalFailure):
+		return exitTotalFailure
+	case errors.Is(err, commands.ErrConfig), errors.Is(err, adyen.ErrUnauthorized):
+		return exitConfig
+	case errors.Is(err, commands.ErrInvalidInput):
+		return exitInvalidInput
+	case errors.Is(err, commands.ErrDrift):
+		return exitDrift
+	case errors.Is(err, commands.ErrNonCompliant):
+		return exitNonCompliant
+	case errors.As(err, &cmdErr):
+		return exitFailure
+	default:
+		return exitInvalidInput
 	}
 }
 
-// newLogger initializes logger for console.
-func newLogger() (*zap.Logger, error) {
-	config := zap.NewProductionConfig()
-	config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	config.Encoding = "console"
-	config.DisableCaller = true
-	config.DisableStacktrace = true
-	config.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
-	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	return config.Build()
+// newSignalContext initializes context, which is done on SIGINT or SIGTERM.
+// The second signal terminates the application immediately.
+func newSignalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// newLogger initializes logger for console (stderr) or for the rotated file.
+// Interactive console keeps the progress line below the logs.
+func newLogger(level, format, file string, console *commands.Console) (*zap.Logger, error) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse log level: %w", err)
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoderConfig.EncodeDuration = zapcore.StringDurationEncoder
+
+	var encoder zapcore.Encoder
+	switch format {
+	case logFormatConsole:
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	case logFormatJSON:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("unsupported log format: %s", format)
+	}
+
+	sink := zapcore.Lock(os.Stderr)
+	if console != nil {
+		sink = console
+	}
+	if file != "" {
+		sink = zapcore.AddSync(&lumberjack.Logger{
+			Filename:   file,
+			MaxSize:    logFileMaxSize,
+			MaxBackups: logFileMaxBackups,
+			MaxAge:     logFileMaxAge,
+		})
+	}
+	return zap.New(zapcore.NewCore(encoder, sink, lvl)), nil
 }
 
 // newHTTPClient initializes HTTP client.
@@ -67,18 +198,206 @@ func newHTTPClient() *http.Client {
 }
 
 // newConfig initializes new configuration.
-func newConfig(logger *zap.Logger) *commands.Config {
+func newConfig() (*commands.Config, error) {
 	var config commands.Config
 	if err := env.Parse(&config); err != nil {
-		logger.
-			With(zap.Error(err)).
-			Fatal("Failed to initialize configuration from the environment")
+		return nil, fmt.Errorf("%w: %w", commands.ErrConfig, err)
+	}
+	return &config, nil
+}
+
+// processor declare any command, which processes the input records.
+type processor interface {
+	Run(ctx context.Context) (*commands.Summary, error)
+}
+
+// resultWriter returns the writer for results of the command, like uploaded IDs: stdout,
+// or stderr, if the summary in JSON goes to stdout.
+func resultWriter(c *cli.Context) io.Writer {
+	if c.String("output") == outputJSON {
+		return c.App.ErrWriter
+	}
+	return c.App.Writer
+}
+
+// run runs the processor and prints the run summary in JSON, if requested.
+// The summary goes to stdout, the logs go to stderr.
+func run(c *cli.Context, p processor) error {
+	summary, err := p.Run(c.Context)
+	if err != nil {
+		err = &commandError{err: err}
+	}
+	if c.String("output") != outputJSON {
+		return err
+	}
+
+	if summary == nil {
+		summary = &commands.Summary{}
+	}
+	summary.Command = c.Command.Name
+	if err != nil {
+		summary.Error = err.Error()
+	}
+
+	encoder := json.NewEncoder(c.App.Writer)
+	encoder.SetIndent("", "  ")
+	if encErr := encoder.Encode(summary); encErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to print the summary: %w", encErr))
+	}
+	return err
+}
+
+// fleetFlags declare the flags to select terminals by the filter.
+func fleetFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "merchant",
+			Usage: "the merchant ID to select terminals of (can be repeated)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "store",
+			Usage: "the store reference to select terminals of (can be repeated)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "model",
+			Usage: "the terminal model to select, e.g. S1F2 (can be repeated)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "status",
+			Usage: "the assignment status to select: boarded, deployed, inventory, etc. (can be repeated)",
+		},
+	}
+}
+
+// selectFlag declare the flag to select terminals by the query instead of CSV.
+func selectFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name: "select",
+		Usage: "the query to select terminals instead of CSV, " +
+			"e.g. 'store=ST123 model=S1F2 firmware<1.90 lastActivity>30d status=boarded'",
+	}
+}
+
+// certificatesFlags declare the flags of certificate commands.
+func certificatesFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:      "csv",
+			TakesFile: true,
+			Usage:     "the full path to CSV file, containing the terminal IDs and the certificate names",
+		},
+		selectFlag(),
+		&cli.StringFlag{
+			Name:  "certificate-name",
+			Usage: "the name of the certificate to install or uninstall on selected terminals",
+		},
+		&cli.StringFlag{
+			Name:  "date",
+			Usage: "the date to install or uninstall the certificate on selected terminals at, now if it's empty",
+		},
+		&cli.BoolFlag{
+			Name:  "prod",
+			Usage: "use this parameter if you want to run on production environment",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "use this parameter if you want to do dry run (no changes will apply)",
+		},
+	}, trackingFlags()...)
+}
+
+// selectedCertificate returns the certificate and the date to install or uninstall on selected terminals.
+func selectedCertificate(c *cli.Context) certificates.Record {
+	return certificates.Record{
+		CertificateName: c.String("certificate-name"),
+		Date:            c.String("date"),
+	}
+}
+
+// trackingFlags declare the flags to track scheduled terminal actions.
+func trackingFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "use this parameter if you want to wait till scheduled actions are finished on terminals",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Value: 30 * time.Minute,
+			Usage: "how long to wait till scheduled actions are finished",
+		},
+		&cli.StringFlag{
+			Name:      "actions",
+			TakesFile: true,
+			Usage:     "the full path to CSV file to write scheduled actions to, use it with actions status command",
+		},
+	}
+}
+
+// tracking initializes tracking of scheduled terminal actions from the tracking flags.
+func tracking(c *cli.Context) schedule.Tracking {
+	return schedule.Tracking{
+		FilePath: c.String("actions"),
+		Wait:     c.Bool("wait"),
+		Timeout:  c.Duration("timeout"),
+	}
+}
+
+// rolloutFlags declare the flags of the staged rollout.
+func rolloutFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:      "state",
+			TakesFile: true,
+			Usage:     "the full path to the rollout state file, install in waves and resume the rollout, if the file exists",
+		},
+		&cli.StringSliceFlag{
+			Name:  "wave",
+			Usage: "the wave of the rollout: 10% of terminals, 50 terminals or stores:REF1;REF2 (can be repeated)",
+		},
+		&cli.Float64Flag{
+			Name:  "failure-threshold",
+			Usage: "the share of failed terminals of the wave in percent, which halts the rollout",
+		},
+	}
+}
+
+// levelFlag declare the flag to select the level of terminal settings.
+func levelFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "level",
+		Value: adyen.LevelTerminal,
+		Usage: "the level of settings: company, merchant, store or terminal",
+	}
+}
+
+// settingsFlags declare the flags to select the level and the entities to operate terminal settings on.
+// Merchants and stores are selected with --merchant and --store, companies with --company.
+func settingsFlags() []cli.Flag {
+	return append([]cli.Flag{
+		levelFlag(),
+		&cli.StringSliceFlag{
+			Name:  "company",
+			Usage: "the company ID to operate on, when the level is company (can be repeated)",
+		},
+	}, fleetFlags()...)
+}
+
+// fleetFilter initializes the filter from the fleet flags.
+func fleetFilter(c *cli.Context) fleet.Filter {
+	return fleet.Filter{
+		MerchantIDs: c.StringSlice("merchant"),
+		StoreIDs:    c.StringSlice("store"),
+		Models:      c.StringSlice("model"),
+		Statuses:    c.StringSlice("status"),
 	}
-	return &config
 }
 
 // newApp initializes new application.
-func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *cli.App { //nolint:funlen
+// Logger and configuration are initialized before any command runs, they depend on the global flags.
+func newApp(client *http.Client) *cli.App { //nolint:funlen
+	var logger *zap.Logger
+	var config *commands.Config
 	return &cli.App{
 		Name:     "adyen-cli",
 		Version:  fmt.Sprintf(version, Commit, Buildstamp),
@@ -91,7 +410,79 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 		},
 		Copyright: "(c) 2023 Anton Krivenko",
 		Usage:     "Operate with your Adyen account via CLI",
-		Action:    cli.ShowAppHelp,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "output",
+				Value: outputText,
+				Usage: "the format of the run summary: text (logs only) or json (printed to stdout)",
+				Action: func(c *cli.Context, output string) error {
+					if output != outputText && output != outputJSON {
+						return fmt.Errorf("%w: unsupported output format: %s", commands.ErrInvalidInput, output)
+					}
+					return nil
+				},
+			},
+			&cli.DurationFlag{
+				Name:    "grace-period",
+				Value:   30 * time.Second,
+				EnvVars: []string{"ADYEN_GRACE_PERIOD"},
+				Usage:   "how long in-flight requests can run after the interruption (Ctrl-C)",
+			},
+			&cli.StringFlag{
+				Name:      "cache-file",
+				TakesFile: true,
+				EnvVars:   []string{"ADYEN_CACHE_FILE"},
+				Usage:     "the full path to the file to cache resolved stores, terminals and account holders",
+			},
+			&cli.DurationFlag{
+				Name:    "cache-ttl",
+				Value:   24 * time.Hour,
+				EnvVars: []string{"ADYEN_CACHE_TTL"},
+				Usage:   "how long cached stores, terminals and account holders are valid",
+			},
+			&cli.StringFlag{
+				Name:  "log-level",
+				Value: zapcore.InfoLevel.String(),
+				Usage: "the minimal log level: debug (full requests and responses), info, warn or error",
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Value: logFormatConsole,
+				Usage: "the log format: console or json",
+			},
+			&cli.StringFlag{
+				Name:      "log-file",
+				TakesFile: true,
+				Usage:     "the full path to the log file (rotated), logs are written to stderr by default",
+			},
+		},
+		Before: func(c *cli.Context) (err error) {
+			console := commands.NewConsole()
+			logger, err = newLogger(c.String("log-level"), c.String("log-format"), c.String("log-file"), console)
+			if err != nil {
+				return fmt.Errorf("%w: failed to create the logger: %w", commands.ErrInvalidInput, err)
+			}
+			config, err = newConfig()
+			if err != nil {
+				logger.
+					With(zap.Error(err)).
+					Error("Failed to initialize configuration from the environment")
+				return err
+			}
+			config.GracePeriod = c.Duration("grace-period")
+			config.CacheFile = c.String("cache-file")
+			config.CacheTTL = c.Duration("cache-ttl")
+			config.RecordOutcomes = c.String("output") == outputJSON
+			config.Console = console
+			return nil
+		},
+		After: func(c *cli.Context) error {
+			if logger != nil {
+				_ = logger.Sync()
+			}
+			return nil
+		},
+		Action: cli.ShowAppHelp,
 		Commands: []*cli.Command{
 			{
 				Name:    "link",
@@ -118,10 +509,9 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := link.New(
+					return run(c, link.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("balance"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.Bool("balance"), c.Bool("prod"), c.Bool("dry-run")))
 				},
 			},
 			{
@@ -149,10 +539,9 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := close.New(
+					return run(c, close.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("store"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.Bool("store"), c.Bool("prod"), c.Bool("dry-run")))
 				},
 			},
 			{
@@ -176,10 +565,9 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := method.New(
+					return run(c, method.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.Bool("prod"), c.Bool("dry-run")))
 				},
 			},
 			{
@@ -203,10 +591,9 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := sweep.New(
+					return run(c, sweep.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.Bool("prod"), c.Bool("dry-run")))
 				},
 			},
 			{
@@ -230,10 +617,9 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := sales.New(
+					return run(c, sales.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.Bool("prod"), c.Bool("dry-run")))
 				},
 			},
 			{
@@ -243,10 +629,22 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:      "csv",
-						Required:  true,
 						TakesFile: true,
 						Usage:     "the full path to CSV file, containing the required data to reassign",
 					},
+					selectFlag(),
+					&cli.StringFlag{
+						Name:  "from-store",
+						Usage: "the store reference to move all terminals from, use it with --to-store",
+					},
+					&cli.StringFlag{
+						Name:  "to-merchant",
+						Usage: "the merchant ID to reassign selected terminals to",
+					},
+					&cli.StringFlag{
+						Name:  "to-store",
+						Usage: "the store reference to reassign selected terminals to",
+					},
 					&cli.BoolFlag{
 						Name:  "prod",
 						Usage: "use this parameter if you want to run on production environment",
@@ -257,10 +655,10 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := reassign.New(
+					return run(c, reassign.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.String("select"), c.String("from-store"), c.String("to-merchant"), c.String("to-store"),
+						c.Bool("prod"), c.Bool("dry-run")))
 				},
 			},
 			{
@@ -270,10 +668,10 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:      "csv",
-						Required:  true,
 						TakesFile: true,
 						Usage:     "the full path to CSV file, containing the terminal IDs",
 					},
+					selectFlag(),
 					&cli.BoolFlag{
 						Name:  "disable",
 						Usage: "use this parameter if you want to disable cellular",
@@ -288,10 +686,9 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := cellular.New(
+					return run(c, cellular.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("disable"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.String("select"), c.Bool("disable"), c.Bool("prod"), c.Bool("dry-run")))
 				},
 			},
 			{
@@ -301,10 +698,11 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:      "csv",
-						Required:  true,
 						TakesFile: true,
-						Usage:     "the full path to CSV file, containing the terminal IDs",
+						Usage:     "the full path to CSV file, containing the terminal IDs (or company, merchant, store IDs depending on the level)",
 					},
+					selectFlag(),
+					levelFlag(),
 					&cli.BoolFlag{
 						Name:  "prod",
 						Usage: "use this parameter if you want to run on production environment",
@@ -315,22 +713,34 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 					},
 				},
 				Action: func(c *cli.Context) error {
-					p := offline.New(
+					return run(c, offline.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("level"), c.String("csv"), c.String("select"), c.Bool("prod"), c.Bool("dry-run")))
 				},
 			}, {
 				Name:    "install",
 				Aliases: []string{"i"},
 				Usage:   "Install apps on terminals",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:      "csv",
-						Required:  true,
 						TakesFile: true,
 						Usage:     "the full path to CSV file, containing the terminal IDs",
 					},
+					selectFlag(),
+					&cli.StringFlag{
+						Name:  "package",
+						Usage: "the package name of the app to install on selected terminals",
+					},
+					&cli.StringFlag{
+						Name:  "version",
+						Value: "latest",
+						Usage: "the version of the app to install on selected terminals: latest, the name, the range or versionCode:N",
+					},
+					&cli.StringFlag{
+						Name:  "date",
+						Usage: "the date to install the app on selected terminals at, now if it's empty",
+					},
 					&cli.BoolFlag{
 						Name:  "prod",
 						Usage: "use this parameter if you want to run on production environment",
@@ -339,12 +749,602 @@ func newApp(logger *zap.Logger, client *http.Client, config *commands.Config) *c
 						Name:  "dry-run",
 						Usage: "use this parameter if you want to do dry run (no changes will apply)",
 					},
+					&cli.BoolFlag{
+						Name:  "local-time",
+						Usage: "use this parameter if dates without the offset are the local time of the store or the terminal",
+					},
+				}, append(trackingFlags(), rolloutFlags()...)...),
+				Action: func(c *cli.Context) error {
+					return run(c, install.New(
+						logger, client, config,
+						c.String("csv"), c.String("select"), install.Record{
+							PackageName: c.String("package"),
+							VersionName: c.String("version"),
+							Date:        c.String("date"),
+						}, tracking(c), rollout.Options{
+							StatePath:        c.String("state"),
+							Waves:            c.StringSlice("wave"),
+							FailureThreshold: c.Float64("failure-threshold"),
+						}, c.Bool("local-time"), c.Bool("prod"), c.Bool("dry-run")))
 				},
+			},
+			{
+				Name:    "uninstall",
+				Aliases: []string{"u"},
+				Usage:   "Uninstall apps from terminals",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:      "csv",
+						TakesFile: true,
+						Usage:     "the full path to CSV file, containing the terminal IDs",
+					},
+					selectFlag(),
+					&cli.StringFlag{
+						Name:  "package",
+						Usage: "the package name of the app to uninstall from selected terminals",
+					},
+					&cli.StringFlag{
+						Name:  "version",
+						Value: "latest",
+						Usage: "the version of the app to uninstall from selected terminals: latest, the name, the range or versionCode:N",
+					},
+					&cli.StringFlag{
+						Name:  "date",
+						Usage: "the date to uninstall the app from selected terminals at, now if it's empty",
+					},
+					&cli.BoolFlag{
+						Name:  "prod",
+						Usage: "use this parameter if you want to run on production environment",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "use this parameter if you want to do dry run (no changes will apply)",
+					},
+				}, trackingFlags()...),
 				Action: func(c *cli.Context) error {
-					p := install.New(
+					return run(c, uninstall.New(
 						logger, client, config,
-						c.String("csv"), c.Bool("prod"), c.Bool("dry-run"))
-					return p.Run(context.Background())
+						c.String("csv"), c.String("select"), uninstall.Record{
+							PackageName: c.String("package"),
+							VersionName: c.String("version"),
+							Date:        c.String("date"),
+						}, tracking(c), c.Bool("prod"), c.Bool("dry-run")))
+				},
+			},
+			{
+				Name:  "rollout",
+				Usage: "Operate with staged rollouts",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "abort",
+						Usage: "Abort the rollout, no more waves are scheduled",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:      "state",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to the rollout state file",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, abort.New(logger, c.String("state")))
+						},
+					},
+				},
+			},
+			{
+				Name:  "actions",
+				Usage: "Operate with scheduled terminal actions",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "status",
+						Usage: "Check statuses of scheduled terminal actions",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:      "csv",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to CSV file, containing scheduled actions (written by --actions)",
+							},
+							&cli.BoolFlag{
+								Name:  "wait",
+								Usage: "use this parameter if you want to wait till actions are finished on terminals",
+							},
+							&cli.DurationFlag{
+								Name:  "timeout",
+								Value: 30 * time.Minute,
+								Usage: "how long to wait till actions are finished",
+							},
+							&cli.StringFlag{
+								Name:      "report",
+								TakesFile: true,
+								Usage:     "the full path to CSV file to write actions with their statuses to",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, actions.New(
+								logger, client, config,
+								c.String("csv"), schedule.Tracking{
+									FilePath: c.String("report"),
+									Wait:     c.Bool("wait"),
+									Timeout:  c.Duration("timeout"),
+								}, c.Bool("prod")))
+						},
+					},
+				},
+			},
+			{
+				Name:  "apps",
+				Usage: "Operate with Android apps in the company library",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "upload",
+						Usage: "Upload APK to the company library",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "company",
+								Required: true,
+								Usage:    "the company ID to upload the app to",
+							},
+							&cli.StringFlag{
+								Name:      "apk",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to APK file",
+							},
+							&cli.DurationFlag{
+								Name:  "timeout",
+								Value: 10 * time.Minute,
+								Usage: "how long to wait till Adyen processes the app",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "use this parameter if you want to do dry run (the manifest is checked, no changes will apply)",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, appsupload.New(
+								logger, client, config, resultWriter(c),
+								c.String("company"), c.String("apk"), c.Duration("timeout"), c.Bool("prod"), c.Bool("dry-run")))
+						},
+					},
+				},
+			},
+			{
+				Name:  "certificates",
+				Usage: "Operate with Android certificates on terminals",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "upload",
+						Usage: "Upload the certificate to the company library",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "company",
+								Required: true,
+								Usage:    "the company ID to upload the certificate to",
+							},
+							&cli.StringFlag{
+								Name:      "certificate",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to PEM or DER encoded certificate file",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "use this parameter if you want to do dry run (the certificate is checked, no changes will apply)",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, certificatesupload.New(
+								logger, client, config, resultWriter(c),
+								c.String("company"), c.String("certificate"), c.Bool("prod"), c.Bool("dry-run")))
+						},
+					},
+					{
+						Name:  "install",
+						Usage: "Install certificates on terminals",
+						Flags: certificatesFlags(),
+						Action: func(c *cli.Context) error {
+							return run(c, certificates.New(
+								logger, client, config,
+								c.String("csv"), c.String("select"), selectedCertificate(c), tracking(c), false,
+								c.Bool("prod"), c.Bool("dry-run")))
+						},
+					},
+					{
+						Name:  "uninstall",
+						Usage: "Uninstall certificates from terminals",
+						Flags: certificatesFlags(),
+						Action: func(c *cli.Context) error {
+							return run(c, certificates.New(
+								logger, client, config,
+								c.String("csv"), c.String("select"), selectedCertificate(c), tracking(c), true,
+								c.Bool("prod"), c.Bool("dry-run")))
+						},
+					},
+				},
+			},
+			{
+				Name:  "stores",
+				Usage: "Operate with stores",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "export",
+						Usage: "Export all stores with their configuration",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:      "out",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to the file to export stores to",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: commands.FormatCSV,
+								Usage: "the format of the export: csv or json",
+							},
+							&cli.StringSliceFlag{
+								Name:  "merchant",
+								Usage: "the merchant ID to export stores of (can be repeated), all company stores by default",
+							},
+							&cli.StringFlag{
+								Name:      "link",
+								TakesFile: true,
+								Usage:     "the full path to CSV file to write balance platform links of stores to, use it with link --balance",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, stores.New(
+								logger, client, config,
+								c.String("out"), c.String("link"), c.String("format"), c.StringSlice("merchant"), c.Bool("prod")))
+						},
+					},
+				},
+			},
+			{
+				Name:  "terminals",
+				Usage: "Operate with terminals",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "export",
+						Usage: "Export the terminals fleet inventory",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:      "out",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to the file to export terminals to",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: commands.FormatCSV,
+								Usage: "the format of the export: csv or json",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						}, fleetFlags()...),
+						Action: func(c *cli.Context) error {
+							return run(c, terminals.New(
+								logger, client, config,
+								c.String("out"), c.String("format"), fleetFilter(c), c.Bool("prod")))
+						},
+					},
+					{
+						Name:  "firmware",
+						Usage: "Check firmware of terminals against the minimum version of their model",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:      "policy",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to CSV file, containing the models and their minimum firmware versions",
+							},
+							&cli.StringFlag{
+								Name:      "out",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to the file to write terminals grouped by model and firmware to",
+							},
+							&cli.StringFlag{
+								Name:      "violations",
+								TakesFile: true,
+								Usage:     "the full path to the file to write terminals with unsupported firmware to",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: commands.FormatCSV,
+								Usage: "the format of the report: csv or json",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						}, fleetFlags()...),
+						Action: func(c *cli.Context) error {
+							return run(c, firmware.New(
+								logger, client, config,
+								c.String("policy"), c.String("out"), c.String("violations"), c.String("format"),
+								fleetFilter(c), c.Bool("prod")))
+						},
+					},
+					{
+						Name:  "stale",
+						Usage: "Find terminals, which were not active for a long time, and return them to the merchant inventory",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:      "out",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to the file to write stale terminals to",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: commands.FormatCSV,
+								Usage: "the format of the report: csv or json",
+							},
+							&cli.IntFlag{
+								Name:  "days",
+								Value: 90,
+								Usage: "the number of days without activity and transactions, after which the terminal is stale",
+							},
+							&cli.StringFlag{
+								Name:      "allowlist",
+								TakesFile: true,
+								Usage:     "the full path to CSV file, containing the terminal IDs or serials, which are never stale",
+							},
+							&cli.StringFlag{
+								Name:      "remediation",
+								TakesFile: true,
+								Usage:     "the full path to CSV file to write stale terminals of stores to, use it with reassign --csv",
+							},
+							&cli.BoolFlag{
+								Name:  "return",
+								Usage: "use this parameter if you want to return stale terminals of stores to the merchant inventory",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "use this parameter if you want to do dry run (no changes will apply)",
+							},
+						}, fleetFlags()...),
+						Action: func(c *cli.Context) error {
+							return run(c, stale.New(
+								logger, client, config,
+								c.String("out"), c.String("format"), fleetFilter(c), c.Int("days"),
+								c.String("allowlist"), c.String("remediation"), c.Bool("return"), c.Bool("prod"), c.Bool("dry-run")))
+						},
+					},
+				},
+			},
+			{
+				Name:  "terminal-settings",
+				Usage: "Operate with terminal settings",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "export",
+						Usage: "Export the settings of terminals",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:      "csv",
+								TakesFile: true,
+								Usage:     "the full path to CSV file, containing the IDs on the level, the filter is used if not defined",
+							},
+							&cli.StringFlag{
+								Name:      "out",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to the file to export settings to",
+							},
+							&cli.StringFlag{
+								Name:  "format",
+								Value: commands.FormatJSON,
+								Usage: "the format of the export: json (one document per terminal) or csv (one row per setting)",
+							},
+							&cli.BoolFlag{
+								Name:  "show-secrets",
+								Usage: "use this parameter if you want to export passwords, passphrases and PINs as is",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						}, settingsFlags()...),
+						Action: func(c *cli.Context) error {
+							return run(c, export.New(
+								logger, client, config,
+								c.String("level"), c.String("csv"), fleetFilter(c), c.StringSlice("company"),
+								c.String("out"), c.String("format"),
+								c.Bool("show-secrets"), c.Bool("prod")))
+						},
+					},
+					{
+						Name:  "apply",
+						Usage: "Apply the settings template to terminals",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:      "template",
+								TakesFile: true,
+								Usage:     "the full path to JSON or YAML file, containing the partial settings to apply",
+							},
+							&cli.StringFlag{
+								Name:      "csv",
+								TakesFile: true,
+								Usage:     "the full path to CSV file with IDs on the level and per-entity overrides, the filter is used if not defined",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "use this parameter if you want to do dry run (differences are logged, no changes will apply)",
+							},
+						}, settingsFlags()...),
+						Action: func(c *cli.Context) error {
+							return run(c, apply.New(
+								logger, client, config,
+								c.String("template"), c.String("level"), c.String("csv"), fleetFilter(c), c.StringSlice("company"),
+								c.Bool("prod"), c.Bool("dry-run")))
+						},
+					},
+					{
+						Name:      "explain",
+						Usage:     "Show the effective settings of the terminal and the level, which defined them",
+						ArgsUsage: "<terminal ID or serial>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:      "out",
+								TakesFile: true,
+								Usage:     "the full path to CSV file to write settings to, the table is printed if not defined",
+							},
+							&cli.BoolFlag{
+								Name:  "show-secrets",
+								Usage: "use this parameter if you want to show passwords, passphrases and PINs as is",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, explain.New(
+								logger, client, config, resultWriter(c),
+								c.Args().First(), c.String("out"), c.Bool("show-secrets"), c.Bool("prod")))
+						},
+					},
+					{
+						Name:  "drift",
+						Usage: "Detect terminal settings, which differ from the baseline",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:      "baseline",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to JSON or YAML file, containing the baseline settings",
+							},
+							&cli.StringFlag{
+								Name:      "csv",
+								TakesFile: true,
+								Usage:     "the full path to CSV file, containing the IDs on the level, the filter is used if not defined",
+							},
+							&cli.StringFlag{
+								Name:      "report",
+								TakesFile: true,
+								Usage:     "the full path to CSV file to write differences to",
+							},
+							&cli.StringFlag{
+								Name:      "remediation",
+								TakesFile: true,
+								Usage:     "the full path to CSV file to write baseline values to, use it with terminal-settings apply --csv",
+							},
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						}, settingsFlags()...),
+						Action: func(c *cli.Context) error {
+							return run(c, drift.New(
+								logger, client, config,
+								c.String("baseline"), c.String("level"), c.String("csv"), fleetFilter(c), c.StringSlice("company"),
+								c.String("report"), c.String("remediation"),
+								c.Bool("prod")))
+						},
+					},
+				},
+			},
+			{
+				Name:  "logos",
+				Usage: "Operate with terminal logos",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "upload",
+						Usage: "Upload PNG or JPG logos to companies, merchants, stores or terminals",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:      "csv",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to CSV file, containing the IDs on the level, the terminal model and the logo",
+							},
+							&cli.StringFlag{
+								Name:      "logo",
+								TakesFile: true,
+								Usage:     "the full path to the logo, used if CSV doesn't define it",
+							},
+							&cli.StringFlag{
+								Name:  "model",
+								Usage: "the terminal model, e.g. S1F2, used if CSV doesn't define it",
+							},
+							levelFlag(),
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "use this parameter if you want to do dry run (logos are validated, no changes will apply)",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, upload.New(
+								logger, client, config,
+								c.String("level"), c.String("csv"), c.String("logo"), c.String("model"),
+								c.Bool("prod"), c.Bool("dry-run")))
+						},
+					},
+					{
+						Name:  "download",
+						Usage: "Download logos of companies, merchants, stores or terminals",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:      "csv",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to CSV file, containing the IDs on the level and the terminal model",
+							},
+							&cli.StringFlag{
+								Name:      "out",
+								Required:  true,
+								TakesFile: true,
+								Usage:     "the full path to the directory to download logos to",
+							},
+							&cli.StringFlag{
+								Name:  "model",
+								Usage: "the terminal model, e.g. S1F2, used if CSV doesn't define it",
+							},
+							levelFlag(),
+							&cli.BoolFlag{
+								Name:  "prod",
+								Usage: "use this parameter if you want to run on production environment",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return run(c, download.New(
+								logger, client, config,
+								c.String("level"), c.String("csv"), c.String("model"), c.String("out"), c.Bool("prod")))
+						},
+					},
 				},
 			},
 		},
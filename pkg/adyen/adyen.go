@@ -4,12 +4,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/settings"
+)
+
+var (
+	// ErrUnauthorized means Adyen rejected the API key.
+	ErrUnauthorized = errors.New("unauthorized")
 )
 
 // API define Adyen API.
@@ -48,7 +61,7 @@ func New(
 func (a *API) AccountHolder(ctx context.Context, accountHolderCode string) (*GetAccountHolderResponse, error) {
 	a.logger.
 		With(zap.String("AccountHolderCode", accountHolderCode)).
-		Info(">> Get Account Holder")
+		Debug(">> Get Account Holder")
 
 	response, err := a.call(
 		ctx,
@@ -68,7 +81,7 @@ func (a *API) AccountHolder(ctx context.Context, accountHolderCode string) (*Get
 	a.logger.
 		With(zap.String("AccountHolderCode", accountHolderCode)).
 		With(zap.Any("Response", accountHolder)).
-		Info("<< Get Account Holder")
+		Debug("<< Get Account Holder")
 	return &accountHolder, nil
 }
 
@@ -76,7 +89,7 @@ func (a *API) AccountHolder(ctx context.Context, accountHolderCode string) (*Get
 func (a *API) UpdateAccountHolder(ctx context.Context, accountHolder *UpdateAccountHolderRequest) error {
 	a.logger.
 		With(zap.Any("AccountHolder", accountHolder)).
-		Info(">> Update Account Holder")
+		Debug(">> Update Account Holder")
 
 	response, err := a.call(
 		ctx,
@@ -96,7 +109,7 @@ func (a *API) UpdateAccountHolder(ctx context.Context, accountHolder *UpdateAcco
 	a.logger.
 		With(zap.Any("AccountHolder", accountHolder)).
 		With(zap.Any("Response", updated)).
-		Info("<< Update Account Holder")
+		Debug("<< Update Account Holder")
 	return nil
 }
 
@@ -104,7 +117,7 @@ func (a *API) UpdateAccountHolder(ctx context.Context, accountHolder *UpdateAcco
 func (a *API) CloseAccountHolder(ctx context.Context, accountHolderCode string) error {
 	a.logger.
 		With(zap.String("AccountHolderCode", accountHolderCode)).
-		Info(">> Close Account Holder")
+		Debug(">> Close Account Holder")
 
 	response, err := a.call(
 		ctx,
@@ -124,7 +137,7 @@ func (a *API) CloseAccountHolder(ctx context.Context, accountHolderCode string)
 	a.logger.
 		With(zap.String("AccountHolderCode", accountHolderCode)).
 		With(zap.Any("Response", closed)).
-		Info("<< Close Account Holder")
+		Debug("<< Close Account Holder")
 	return nil
 }
 
@@ -134,7 +147,7 @@ func (a *API) UpdateSplitConfiguration(
 	a.logger.
 		With(zap.String("MerchantID", merchantID)).
 		With(zap.Any("SplitConfiguration", config)).
-		Info(">> Update Split Configuration")
+		Debug(">> Update Split Configuration")
 
 	response, err := a.call(
 		ctx,
@@ -155,7 +168,7 @@ func (a *API) UpdateSplitConfiguration(
 		With(zap.String("MerchantID", merchantID)).
 		With(zap.Any("SplitConfiguration", config)).
 		With(zap.Any("Response", updated)).
-		Info("<< Update Split Configuration")
+		Debug("<< Update Split Configuration")
 	return nil
 }
 
@@ -163,7 +176,7 @@ func (a *API) UpdateSplitConfiguration(
 func (a *API) SearchStores(ctx context.Context, storeID string) (*SearchStoresResponse, error) {
 	a.logger.
 		With(zap.String("StoreID", storeID)).
-		Info(">> Get All Store")
+		Debug(">> Get All Store")
 
 	response, err := a.call(
 		ctx,
@@ -183,7 +196,100 @@ func (a *API) SearchStores(ctx context.Context, storeID string) (*SearchStoresRe
 	a.logger.
 		With(zap.String("StoreID", storeID)).
 		With(zap.Any("Response", stores)).
-		Info("<< Get All Store")
+		Debug("<< Get All Store")
+	return &stores, nil
+}
+
+// Stores gets one page of all stores.
+func (a *API) Stores(ctx context.Context, pageNumber, pageSize int) (*SearchStoresResponse, error) {
+	a.logger.
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
+		Debug(">> Get Stores Page")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("https://%s/v3/stores?pageNumber=%d&pageSize=%d", a.mgmtURL, pageNumber, pageSize),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stores page: %w", err)
+	}
+
+	var stores SearchStoresResponse
+	if err := json.Unmarshal(response, &stores); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
+		With(zap.Any("Response", stores)).
+		Debug("<< Get Stores Page")
+	return &stores, nil
+}
+
+// Store gets store by management ID.
+func (a *API) Store(ctx context.Context, storeMgmtID string) (*GetStoreResponse, error) {
+	a.logger.
+		With(zap.String("StoreID", storeMgmtID)).
+		Debug(">> Get Store")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("https://%s/v3/stores/%s", a.mgmtURL, storeMgmtID),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	var store GetStoreResponse
+	if err := json.Unmarshal(response, &store); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.String("StoreID", storeMgmtID)).
+		With(zap.Any("Response", store)).
+		Debug("<< Get Store")
+	return &store, nil
+}
+
+// MerchantStores gets one page of the merchant's stores.
+func (a *API) MerchantStores(
+	ctx context.Context, merchantID string, pageNumber, pageSize int,
+) (*SearchStoresResponse, error) {
+	a.logger.
+		With(zap.String("MerchantID", merchantID)).
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
+		Debug(">> Get Merchant Stores Page")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("https://%s/v3/merchants/%s/stores?pageNumber=%d&pageSize=%d",
+			a.mgmtURL, merchantID, pageNumber, pageSize),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merchant stores page: %w", err)
+	}
+
+	var stores SearchStoresResponse
+	if err := json.Unmarshal(response, &stores); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.String("MerchantID", merchantID)).
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
+		With(zap.Any("Response", stores)).
+		Debug("<< Get Merchant Stores Page")
 	return &stores, nil
 }
 
@@ -192,7 +298,7 @@ func (a *API) SetStoreStatus(ctx context.Context, storeMgmtID, status string) er
 	a.logger.
 		With(zap.String("StoreID", storeMgmtID)).
 		With(zap.String("Status", status)).
-		Info(">> Set Store Status")
+		Debug(">> Set Store Status")
 
 	response, err := a.call(
 		ctx,
@@ -213,7 +319,7 @@ func (a *API) SetStoreStatus(ctx context.Context, storeMgmtID, status string) er
 		With(zap.String("StoreID", storeMgmtID)).
 		With(zap.String("Status", status)).
 		With(zap.Any("Response", store)).
-		Info("<< Set Store Status")
+		Debug("<< Set Store Status")
 	return nil
 }
 
@@ -227,7 +333,7 @@ func (a *API) AddPaymentMethod(
 		With(zap.String("BusinessLineID", businessLineID)).
 		With(zap.String("Method", method)).
 		With(zap.String("Currency", currency)).
-		Info(">> Add Payment Method")
+		Debug(">> Add Payment Method")
 
 	req := AddPaymentMethodRequest{
 		Type:           method,
@@ -257,7 +363,7 @@ func (a *API) AddPaymentMethod(
 		With(zap.String("Method", method)).
 		With(zap.String("Currency", currency)).
 		With(zap.Any("Response", resp)).
-		Info("<< Add Payment Method")
+		Debug("<< Add Payment Method")
 	return &resp, nil
 }
 
@@ -267,7 +373,7 @@ func (a *API) BalanceAccount(
 ) (*GetBalanceAccountResponse, error) {
 	a.logger.
 		With(zap.String("BalanceID", balanceID)).
-		Info(">> Balance Account")
+		Debug(">> Balance Account")
 
 	response, err := a.call(
 		ctx,
@@ -287,7 +393,7 @@ func (a *API) BalanceAccount(
 	a.logger.
 		With(zap.String("BalanceID", balanceID)).
 		With(zap.Any("Response", resp)).
-		Info("<< Balance Account")
+		Debug("<< Balance Account")
 	return &resp, nil
 }
 
@@ -297,7 +403,7 @@ func (a *API) BalanceAccountHolder(
 ) (*GetBalanceAccountHolderResponse, error) {
 	a.logger.
 		With(zap.String("AccountHolderID", accountHolderID)).
-		Info(">> Balance Account Holder")
+		Debug(">> Balance Account Holder")
 
 	response, err := a.call(
 		ctx,
@@ -317,7 +423,7 @@ func (a *API) BalanceAccountHolder(
 	a.logger.
 		With(zap.String("AccountHolderID", accountHolderID)).
 		With(zap.Any("Response", resp)).
-		Info("<< Balance Account Holder")
+		Debug("<< Balance Account Holder")
 	return &resp, nil
 }
 
@@ -325,7 +431,7 @@ func (a *API) BalanceAccountHolder(
 func (a *API) LegalEntity(ctx context.Context, legalEntityID string) (*GetLegalEntityResponse, error) {
 	a.logger.
 		With(zap.String("LegalEntityID", legalEntityID)).
-		Info(">> Legal Entity")
+		Debug(">> Legal Entity")
 
 	response, err := a.call(
 		ctx,
@@ -345,7 +451,7 @@ func (a *API) LegalEntity(ctx context.Context, legalEntityID string) (*GetLegalE
 	a.logger.
 		With(zap.String("LegalEntityID", legalEntityID)).
 		With(zap.Any("Response", resp)).
-		Info("<< Legal Entity")
+		Debug("<< Legal Entity")
 	return &resp, nil
 }
 
@@ -353,7 +459,7 @@ func (a *API) LegalEntity(ctx context.Context, legalEntityID string) (*GetLegalE
 func (a *API) Sweeps(ctx context.Context, balanceID string) (*GetSweepsResponse, error) {
 	a.logger.
 		With(zap.String("BalanceID", balanceID)).
-		Info(">> Sweeps")
+		Debug(">> Sweeps")
 
 	response, err := a.call(
 		ctx,
@@ -373,7 +479,7 @@ func (a *API) Sweeps(ctx context.Context, balanceID string) (*GetSweepsResponse,
 	a.logger.
 		With(zap.String("BalanceID", balanceID)).
 		With(zap.Any("Response", resp)).
-		Info("<< Sweeps")
+		Debug("<< Sweeps")
 	return &resp, nil
 }
 
@@ -383,7 +489,7 @@ func (a *API) UpdateSweep(ctx context.Context, balanceID, sweepID, transferInstr
 		With(zap.String("BalanceID", balanceID)).
 		With(zap.String("SweepID", sweepID)).
 		With(zap.String("TransferInstrumentID", transferInstrumentID)).
-		Info(">> Update Sweep")
+		Debug(">> Update Sweep")
 
 	req := UpdateSweepRequest{}
 	req.Counterparty.TransferInstrumentID = transferInstrumentID
@@ -409,7 +515,7 @@ func (a *API) UpdateSweep(ctx context.Context, balanceID, sweepID, transferInstr
 		With(zap.String("SweepID", sweepID)).
 		With(zap.String("TransferInstrumentID", transferInstrumentID)).
 		With(zap.Any("Response", resp)).
-		Info("<< Update Sweep")
+		Debug("<< Update Sweep")
 	return &resp, nil
 }
 
@@ -422,7 +528,7 @@ func (a *API) SetSalesCloseTime(
 		With(zap.String("ClosingTime", closingTime)).
 		With(zap.String("TimeZone", timeZone)).
 		With(zap.Int("Delays", delays)).
-		Info(">> Change Sales Close Time")
+		Debug(">> Change Sales Close Time")
 
 	req := SetSalesCloseTimeRequest{}
 	req.TimeZone = timeZone
@@ -450,7 +556,7 @@ func (a *API) SetSalesCloseTime(
 		With(zap.String("TimeZone", timeZone)).
 		With(zap.Int("Delays", delays)).
 		With(zap.Any("Response", resp)).
-		Info("<< Change Sales Close Time")
+		Debug("<< Change Sales Close Time")
 	return &resp, nil
 }
 
@@ -460,7 +566,7 @@ func (a *API) ReassignTerminal(ctx context.Context, terminalID, merchantID, stor
 		With(zap.String("TerminalID", terminalID)).
 		With(zap.String("MerchantID", merchantID)).
 		With(zap.String("StoreID", storeID)).
-		Info(">> Re-assign Terminal")
+		Debug(">> Re-assign Terminal")
 
 	req := ReassignTerminalRequest{}
 	switch {
@@ -487,20 +593,20 @@ func (a *API) ReassignTerminal(ctx context.Context, terminalID, merchantID, stor
 		With(zap.String("TerminalID", terminalID)).
 		With(zap.String("MerchantID", merchantID)).
 		With(zap.String("StoreID", storeID)).
-		Info("<< Re-assign Terminal")
+		Debug("<< Re-assign Terminal")
 	return nil
 }
 
-// TerminalSettings gets terminal settings.
-func (a *API) TerminalSettings(ctx context.Context, terminalID string) (*TerminalSettingsResponse, error) {
+// TerminalSettings gets terminal settings of the company, merchant, store or terminal.
+func (a *API) TerminalSettings(ctx context.Context, target SettingsTarget) (*TerminalSettingsResponse, error) {
 	a.logger.
-		With(zap.String("TerminalID", terminalID)).
-		Info(">> Get Terminal Settings")
+		With(zap.Stringer("Target", target)).
+		Debug(">> Get Terminal Settings")
 
 	response, err := a.call(
 		ctx,
 		http.MethodGet,
-		fmt.Sprintf("https://%s/v3/terminals/%s/terminalSettings", a.mgmtURL, terminalID),
+		a.settingsURL(target),
 		a.mgmtKey,
 		nil)
 	if err != nil {
@@ -513,18 +619,139 @@ func (a *API) TerminalSettings(ctx context.Context, terminalID string) (*Termina
 	}
 
 	a.logger.
-		With(zap.String("TerminalID", terminalID)).
-		With(zap.Any("Response", settings)).
-		Info("<< Get Terminal Settings")
+		With(zap.Stringer("Target", target)).
+		With(zap.Any("Response", maskSettings(settings))).
+		Debug("<< Get Terminal Settings")
 	return &settings, nil
 }
 
+// TerminalSettingsDocument gets all terminal settings as the generic document, unknown fields are kept as is.
+func (a *API) TerminalSettingsDocument(ctx context.Context, target SettingsTarget) (map[string]interface{}, error) {
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		Debug(">> Get Terminal Settings Document")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		a.settingsURL(target),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get terminal settings: %w", err)
+	}
+
+	settings, err := unmarshalDocument(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		With(zap.Any("Response", maskSettings(settings))).
+		Debug("<< Get Terminal Settings Document")
+	return settings, nil
+}
+
+// PatchTerminalSettings updates only the terminal settings, which are defined in the partial settings document.
+func (a *API) PatchTerminalSettings(
+	ctx context.Context, target SettingsTarget, settings map[string]interface{},
+) (map[string]interface{}, error) {
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		With(zap.Any("Settings", maskSettings(settings))).
+		Debug(">> Patch Terminal Settings")
+
+	response, err := a.call(
+		ctx,
+		http.MethodPatch,
+		a.settingsURL(target),
+		a.mgmtKey,
+		settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch terminal settings: %w", err)
+	}
+
+	updated, err := unmarshalDocument(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		With(zap.Any("Settings", maskSettings(settings))).
+		With(zap.Any("Response", maskSettings(updated))).
+		Debug("<< Patch Terminal Settings")
+	return updated, nil
+}
+
+// TerminalLogo gets the base64 encoded logo of the terminal model, the model is ignored on the terminal level.
+func (a *API) TerminalLogo(ctx context.Context, target SettingsTarget, model string) (string, error) {
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		With(zap.String("Model", model)).
+		Debug(">> Get Terminal Logo")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		a.logoURL(target, model),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get terminal logo: %w", err)
+	}
+
+	var logo TerminalLogo
+	if err := json.Unmarshal(response, &logo); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		With(zap.String("Model", model)).
+		With(zap.Int("Size", len(logo.Data))).
+		Debug("<< Get Terminal Logo")
+	return logo.Data, nil
+}
+
+// UpdateTerminalLogo uploads the base64 encoded logo of the terminal model, the model is ignored on the terminal level.
+func (a *API) UpdateTerminalLogo(ctx context.Context, target SettingsTarget, model, data string) error {
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		With(zap.String("Model", model)).
+		With(zap.Int("Size", len(data))).
+		Debug(">> Update Terminal Logo")
+
+	response, err := a.call(
+		ctx,
+		http.MethodPatch,
+		a.logoURL(target, model),
+		a.mgmtKey,
+		&TerminalLogo{Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to update terminal logo: %w", err)
+	}
+
+	var logo TerminalLogo
+	if err := json.Unmarshal(response, &logo); err != nil {
+		return fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.Stringer("Target", target)).
+		With(zap.String("Model", model)).
+		With(zap.Int("Size", len(logo.Data))).
+		Debug("<< Update Terminal Logo")
+	return nil
+}
+
 // SetSimCardStatus set sim card status.
 func (a *API) SetSimCardStatus(ctx context.Context, terminalID string, disable bool) error {
 	a.logger.
 		With(zap.String("TerminalID", terminalID)).
 		With(zap.Bool("Disable", disable)).
-		Info(">> Set Sim Card Status")
+		Debug(">> Set Sim Card Status")
 
 	req := SetSimCardStatusRequest{}
 	if disable {
@@ -551,22 +778,22 @@ func (a *API) SetSimCardStatus(ctx context.Context, terminalID string, disable b
 	a.logger.
 		With(zap.String("TerminalID", terminalID)).
 		With(zap.Bool("Disable", disable)).
-		With(zap.Any("Response", updated)).
-		Info("<< Set Sim Card Status")
+		With(zap.Any("Response", maskSettings(updated))).
+		Debug("<< Set Sim Card Status")
 	return nil
 }
 
-// DisableOfflinePayments disables offline payments per device.
-func (a *API) DisableOfflinePayments(ctx context.Context, terminalID string, settings SetOfflinePaymentsRequest) error {
+// DisableOfflinePayments disables offline payments of the company, merchant, store or terminal.
+func (a *API) DisableOfflinePayments(ctx context.Context, target SettingsTarget, settings SetOfflinePaymentsRequest) error {
 	a.logger.
-		With(zap.String("TerminalID", terminalID)).
+		With(zap.Stringer("Target", target)).
 		With(zap.Any("Settings", settings)).
-		Info(">> Disable Offline Payments")
+		Debug(">> Disable Offline Payments")
 
 	response, err := a.call(
 		ctx,
 		http.MethodPatch,
-		fmt.Sprintf("https://%s/v3/terminals/%s/terminalSettings", a.mgmtURL, terminalID),
+		a.settingsURL(target),
 		a.mgmtKey,
 		&settings)
 	if err != nil {
@@ -579,10 +806,10 @@ func (a *API) DisableOfflinePayments(ctx context.Context, terminalID string, set
 	}
 
 	a.logger.
-		With(zap.String("TerminalID", terminalID)).
+		With(zap.Stringer("Target", target)).
 		With(zap.Any("Settings", settings)).
-		With(zap.Any("Response", updated)).
-		Info("<< Disable Offline Payments")
+		With(zap.Any("Response", maskSettings(updated))).
+		Debug("<< Disable Offline Payments")
 	return nil
 }
 
@@ -591,7 +818,7 @@ func (a *API) SearchTerminals(ctx context.Context, storeID, searchQuery string)
 	a.logger.
 		With(zap.String("StoreID", storeID)).
 		With(zap.String("SearchQuery", searchQuery)).
-		Info(">> Get Store Terminals")
+		Debug(">> Get Store Terminals")
 
 	response, err := a.call(
 		ctx,
@@ -612,21 +839,78 @@ func (a *API) SearchTerminals(ctx context.Context, storeID, searchQuery string)
 		With(zap.String("StoreID", storeID)).
 		With(zap.String("SearchQuery", searchQuery)).
 		With(zap.Any("Response", terminals)).
-		Info("<< Get Store Terminals")
+		Debug("<< Get Store Terminals")
 	return &terminals, nil
 }
 
-// SearchAndroidApps gets the list of android apps.
-func (a *API) SearchAndroidApps(ctx context.Context, companyID, packageName string) (*SearchAndroidAppsResponse, error) {
+// Terminals gets one page of all terminals, optionally filtered.
+func (a *API) Terminals(
+	ctx context.Context, filter *TerminalsFilter, pageNumber, pageSize int,
+) (*SearchTerminalsResponse, error) {
+	a.logger.
+		With(zap.Any("Filter", filter)).
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
+		Debug(">> Get Terminals Page")
+
+	query := url.Values{}
+	if filter != nil {
+		if len(filter.MerchantIDs) > 0 {
+			query.Set("merchantIds", strings.Join(filter.MerchantIDs, ","))
+		}
+		if len(filter.StoreIDs) > 0 {
+			query.Set("storeIds", strings.Join(filter.StoreIDs, ","))
+		}
+		if len(filter.BrandModels) > 0 {
+			query.Set("brandModels", strings.Join(filter.BrandModels, ","))
+		}
+		if filter.SearchQuery != "" {
+			query.Set("searchQuery", filter.SearchQuery)
+		}
+	}
+	query.Set("pageNumber", strconv.Itoa(pageNumber))
+	query.Set("pageSize", strconv.Itoa(pageSize))
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("https://%s/v3/terminals?%s", a.mgmtURL, query.Encode()),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get terminals page: %w", err)
+	}
+
+	var terminals SearchTerminalsResponse
+	if err := json.Unmarshal(response, &terminals); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.Any("Filter", filter)).
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
+		With(zap.Any("Response", terminals)).
+		Debug("<< Get Terminals Page")
+	return &terminals, nil
+}
+
+// SearchAndroidApps gets one page of android apps of the package.
+func (a *API) SearchAndroidApps(
+	ctx context.Context, companyID, packageName string, pageNumber, pageSize int,
+) (*SearchAndroidAppsResponse, error) {
 	a.logger.
 		With(zap.String("CompanyID", companyID)).
 		With(zap.String("PackageName", packageName)).
-		Info(">> Get Android Apps")
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
+		Debug(">> Get Android Apps")
 
 	response, err := a.call(
 		ctx,
 		http.MethodGet,
-		fmt.Sprintf("https://%s/v3/companies/%s/androidApps?packageName=%s", a.mgmtURL, companyID, packageName),
+		fmt.Sprintf("https://%s/v3/companies/%s/androidApps?packageName=%s&pageNumber=%d&pageSize=%d",
+			a.mgmtURL, companyID, url.QueryEscape(packageName), pageNumber, pageSize),
 		a.mgmtKey,
 		nil)
 	if err != nil {
@@ -641,27 +925,156 @@ func (a *API) SearchAndroidApps(ctx context.Context, companyID, packageName stri
 	a.logger.
 		With(zap.String("CompanyID", companyID)).
 		With(zap.String("PackageName", packageName)).
+		With(zap.Int("PageNumber", pageNumber)).
+		With(zap.Int("PageSize", pageSize)).
 		With(zap.Any("Response", apps)).
-		Info("<< Get Android Apps")
+		Debug("<< Get Android Apps")
 	return &apps, nil
 }
 
-// InstallAndroidApp schedule action to install android app.
-func (a *API) InstallAndroidApp(ctx context.Context, appID, storeID string, terminalIDs []string, at string) error {
+// AndroidApp gets the android app version of the company.
+func (a *API) AndroidApp(ctx context.Context, companyID, appID string) (*AndroidApp, error) {
 	a.logger.
+		With(zap.String("CompanyID", companyID)).
 		With(zap.String("AppID", appID)).
+		Debug(">> Get Android App")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("https://%s/v3/companies/%s/androidApps/%s", a.mgmtURL, companyID, appID),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app: %w", err)
+	}
+
+	var app AndroidApp
+	if err := json.Unmarshal(response, &app); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("AppID", appID)).
+		With(zap.Any("Response", app)).
+		Debug("<< Get Android App")
+	return &app, nil
+}
+
+// UploadAndroidApp uploads APK to the android library of the company, Adyen processes it asynchronously.
+func (a *API) UploadAndroidApp(ctx context.Context, companyID, fileName string, apk []byte) (string, error) {
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("FileName", fileName)).
+		With(zap.Int("Size", len(apk))).
+		Debug(">> Upload Android App")
+
+	response, err := a.upload(
+		ctx,
+		fmt.Sprintf("https://%s/v3/companies/%s/androidApps", a.mgmtURL, companyID),
+		a.mgmtKey,
+		"apk",
+		fileName,
+		apk)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload app: %w", err)
+	}
+
+	var uploaded UploadResponse
+	if err := json.Unmarshal(response, &uploaded); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("FileName", fileName)).
+		With(zap.Any("Response", uploaded)).
+		Debug("<< Upload Android App")
+	return uploaded.ID, nil
+}
+
+// UploadAndroidCertificate uploads the certificate to the android library of the company.
+func (a *API) UploadAndroidCertificate(ctx context.Context, companyID, fileName string, certificate []byte) (string, error) {
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("FileName", fileName)).
+		Debug(">> Upload Android Certificate")
+
+	response, err := a.upload(
+		ctx,
+		fmt.Sprintf("https://%s/v3/companies/%s/androidCertificates", a.mgmtURL, companyID),
+		a.mgmtKey,
+		"certificate",
+		fileName,
+		certificate)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload certificate: %w", err)
+	}
+
+	var uploaded UploadResponse
+	if err := json.Unmarshal(response, &uploaded); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("FileName", fileName)).
+		With(zap.Any("Response", uploaded)).
+		Debug("<< Upload Android Certificate")
+	return uploaded.ID, nil
+}
+
+// SearchAndroidCertificates gets android certificates of the company with the given name.
+func (a *API) SearchAndroidCertificates(
+	ctx context.Context, companyID, certificateName string,
+) (*SearchAndroidCertificatesResponse, error) {
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("CertificateName", certificateName)).
+		Debug(">> Get Android Certificates")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("https://%s/v3/companies/%s/androidCertificates?certificateName=%s",
+			a.mgmtURL, companyID, url.QueryEscape(certificateName)),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all certificates: %w", err)
+	}
+
+	var certificates SearchAndroidCertificatesResponse
+	if err := json.Unmarshal(response, &certificates); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("CertificateName", certificateName)).
+		With(zap.Any("Response", certificates)).
+		Debug("<< Get Android Certificates")
+	return &certificates, nil
+}
+
+// ScheduleAction schedules the action, like app or certificate installation, on terminals.
+func (a *API) ScheduleAction(
+	ctx context.Context, details ActionDetails, storeID string, terminalIDs []string, at string,
+) (*ScheduleActionResponse, error) {
+	a.logger.
+		With(zap.Any("ActionDetails", details)).
 		With(zap.String("StoreID", storeID)).
 		With(zap.Strings("TerminalIDs", terminalIDs)).
 		With(zap.String("ScheduledAt", at)).
-		Info(">> Install Android App")
+		Debug(">> Schedule Action")
 
 	req := ScheduleActionRequest{
-		TerminalIDs: terminalIDs,
-		StoreID:     storeID,
-		ScheduledAt: at,
+		TerminalIDs:   terminalIDs,
+		StoreID:       storeID,
+		ScheduledAt:   at,
+		ActionDetails: details,
 	}
-	req.ActionDetails.Type = "InstallAndroidApp"
-	req.ActionDetails.AppID = appID
 
 	response, err := a.call(
 		ctx,
@@ -670,22 +1083,81 @@ func (a *API) InstallAndroidApp(ctx context.Context, appID, storeID string, term
 		a.mgmtKey,
 		&req)
 	if err != nil {
-		return fmt.Errorf("failed to schedule app install: %w", err)
+		return nil, fmt.Errorf("failed to schedule %s: %w", details.Type, err)
 	}
 
 	var scheduled ScheduleActionResponse
 	if err := json.Unmarshal(response, &scheduled); err != nil {
-		return fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
 	}
 
 	a.logger.
-		With(zap.String("AppID", appID)).
+		With(zap.Any("ActionDetails", details)).
 		With(zap.String("StoreID", storeID)).
 		With(zap.Strings("TerminalIDs", terminalIDs)).
 		With(zap.String("ScheduledAt", at)).
-		With(zap.Any("Response", response)).
-		Info("<< Install Android App")
-	return nil
+		With(zap.Any("Response", scheduled)).
+		Debug("<< Schedule Action")
+	return &scheduled, nil
+}
+
+// TerminalAction gets the scheduled action on the terminal.
+func (a *API) TerminalAction(ctx context.Context, companyID, actionID string) (*TerminalAction, error) {
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("ActionID", actionID)).
+		Debug(">> Get Terminal Action")
+
+	response, err := a.call(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("https://%s/v3/companies/%s/terminalActions/%s", a.mgmtURL, companyID, actionID),
+		a.mgmtKey,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get terminal action: %w", err)
+	}
+
+	var action TerminalAction
+	if err := json.Unmarshal(response, &action); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Adyen response: %w", err)
+	}
+
+	a.logger.
+		With(zap.String("CompanyID", companyID)).
+		With(zap.String("ActionID", actionID)).
+		With(zap.Any("Response", action)).
+		Debug("<< Get Terminal Action")
+	return &action, nil
+}
+
+// settingsURL returns URL of terminal settings of the level.
+func (a *API) settingsURL(target SettingsTarget) string {
+	switch target.Level {
+	case LevelCompany:
+		return fmt.Sprintf("https://%s/v3/companies/%s/terminalSettings", a.mgmtURL, target.ID)
+	case LevelMerchant:
+		return fmt.Sprintf("https://%s/v3/merchants/%s/terminalSettings", a.mgmtURL, target.ID)
+	case LevelStore:
+		return fmt.Sprintf("https://%s/v3/stores/%s/terminalSettings", a.mgmtURL, target.ID)
+	default:
+		return fmt.Sprintf("https://%s/v3/terminals/%s/terminalSettings", a.mgmtURL, target.ID)
+	}
+}
+
+// logoURL returns URL of the terminal logo of the level.
+func (a *API) logoURL(target SettingsTarget, model string) string {
+	query := url.Values{"model": []string{model}}.Encode()
+	switch target.Level {
+	case LevelCompany:
+		return fmt.Sprintf("https://%s/v3/companies/%s/terminalLogos?%s", a.mgmtURL, target.ID, query)
+	case LevelMerchant:
+		return fmt.Sprintf("https://%s/v3/merchants/%s/terminalLogos?%s", a.mgmtURL, target.ID, query)
+	case LevelStore:
+		return fmt.Sprintf("https://%s/v3/stores/%s/terminalLogos?%s", a.mgmtURL, target.ID, query)
+	default:
+		return fmt.Sprintf("https://%s/v3/terminals/%s/terminalLogos", a.mgmtURL, target.ID)
+	}
 }
 
 func (a *API) call(ctx context.Context, method, url, key string, data interface{}) ([]byte, error) {
@@ -697,21 +1169,43 @@ func (a *API) call(ctx context.Context, method, url, key string, data interface{
 		}
 		body = bytes.NewReader(buf)
 	}
+	return a.do(ctx, method, url, key, "application/json", body)
+}
+
+// upload posts the file as multipart form.
+func (a *API) upload(ctx context.Context, url, key, field, fileName string, content []byte) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form: %w", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, fmt.Errorf("failed to create form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create form: %w", err)
+	}
+	return a.do(ctx, http.MethodPost, url, key, writer.FormDataContentType(), &body)
+}
 
+func (a *API) do(ctx context.Context, method, url, key, contentType string, body io.Reader) ([]byte, error) {
 	request, err := http.NewRequestWithContext(ctx, method, url, body)
-	request.Header.Add("Content-Type", "application/json")
-	request.Header.Add("x-API-key", key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	request.Header.Add("Content-Type", contentType)
+	request.Header.Add("x-API-key", key)
 
+	started := time.Now()
 	response, err := a.client.Do(request) // nolint:bodyclose
 	defer closeResponse(response)
+	a.logCall(request, response, time.Since(started), err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call Adyen: %w", err)
 	}
 	if response != nil && response.StatusCode != http.StatusOK {
-		return nil, a.createError(response.Body)
+		return nil, a.createError(response)
 	}
 	if response == nil {
 		return nil, fmt.Errorf("failed to call Adyen, surprising nil response")
@@ -720,12 +1214,33 @@ func (a *API) call(ctx context.Context, method, url, key string, data interface{
 	return io.ReadAll(response.Body)
 }
 
-func (a *API) createError(response io.Reader) error {
-	body, _ := io.ReadAll(response)
+// logCall logs one line per call, full requests and responses are logged on debug level.
+func (a *API) logCall(request *http.Request, response *http.Response, latency time.Duration, err error) {
+	logger := a.logger.
+		With(zap.String("Method", request.Method)).
+		With(zap.String("Endpoint", request.URL.Path)).
+		With(zap.Duration("Latency", latency))
+	if err != nil || response == nil {
+		logger.
+			With(zap.Error(err)).
+			Warn("Adyen call failed")
+		return
+	}
+	logger.
+		With(zap.Int("Status", response.StatusCode)).
+		Info("Adyen call")
+}
+
+func (a *API) createError(response *http.Response) error {
+	body, _ := io.ReadAll(response.Body)
 	a.logger.
 		With(zap.ByteString("Response", body)).
 		Error("Failed call")
 
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: HTTP status: %d", ErrUnauthorized, response.StatusCode)
+	}
+
 	var adyenErr Error
 	if err := json.Unmarshal(body, &adyenErr); err != nil {
 		return fmt.Errorf("failed to unmarshal Adyen error: %w", err)
@@ -733,6 +1248,31 @@ func (a *API) createError(response io.Reader) error {
 	return fmt.Errorf("failed to call Adyen: %s (%s), HTTP status: %d", adyenErr.Title, adyenErr.Detail, adyenErr.Status)
 }
 
+// unmarshalDocument unmarshal the generic JSON document, numbers are kept as is.
+// maskSettings returns terminal settings with secret values masked, so secrets are never logged.
+func maskSettings(v interface{}) map[string]interface{} {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	document, err := unmarshalDocument(buf)
+	if err != nil {
+		return nil
+	}
+	return settings.Mask(document)
+}
+
+func unmarshalDocument(buf []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
 func closeResponse(response *http.Response) {
 	if response != nil {
 		if response.Body != nil {
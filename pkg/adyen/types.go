@@ -81,17 +81,34 @@ type SetStoreStatusRequest struct {
 
 // GetStoreResponse declare get store information response.
 type GetStoreResponse struct {
-	ID              string   `json:"id"`
-	MerchantID      string   `json:"merchantId"`
-	BusinessLineIDs []string `json:"businessLineIds"`
-	Reference       string   `json:"reference"`
-	Status          string   `json:"status"`
+	ID               string   `json:"id"`
+	MerchantID       string   `json:"merchantId"`
+	BusinessLineIDs  []string `json:"businessLineIds"`
+	Reference        string   `json:"reference"`
+	Status           string   `json:"status"`
+	Description      string   `json:"description"`
+	ShopperStatement string   `json:"shopperStatement"`
+	PhoneNumber      string   `json:"phoneNumber"`
+	Address          struct {
+		Line1           string `json:"line1"`
+		Line2           string `json:"line2"`
+		Line3           string `json:"line3"`
+		City            string `json:"city"`
+		PostalCode      string `json:"postalCode"`
+		StateOrProvince string `json:"stateOrProvince"`
+		Country         string `json:"country"`
+	} `json:"address"`
+	SplitConfiguration struct {
+		BalanceAccountID     string `json:"balanceAccountId"`
+		SplitConfigurationID string `json:"splitConfigurationId"`
+	} `json:"splitConfiguration"`
 }
 
 // SearchStoresResponse declare get all stores response.
 type SearchStoresResponse struct {
 	Data       []GetStoreResponse `json:"data"`
 	ItemsTotal int64              `json:"itemsTotal"`
+	PagesTotal int                `json:"pagesTotal"`
 }
 
 // AddPaymentMethodRequest declare add payment method request.
@@ -358,6 +375,31 @@ type SetOfflinePaymentsRequest struct {
 	} `json:"storeAndForward"`
 }
 
+// Terminal settings levels, settings are inherited from the company down to the terminal.
+
+const (
+	LevelCompany  = "company"
+	LevelMerchant = "merchant"
+	LevelStore    = "store"
+	LevelTerminal = "terminal"
+)
+
+// SettingsTarget declare the owner of terminal settings: company, merchant, store or terminal.
+type SettingsTarget struct {
+	Level string
+	ID    string
+}
+
+// String returns human-readable target.
+func (t SettingsTarget) String() string {
+	return t.Level + " " + t.ID
+}
+
+// TerminalLogo declare the request and the response of terminal logo requests.
+type TerminalLogo struct {
+	Data string `json:"data"`
+}
+
 // TerminalSettingsResponse declare response with terminal settings.
 type TerminalSettingsResponse struct {
 	CardholderReceipt struct {
@@ -482,74 +524,155 @@ type TerminalSettingsResponse struct {
 	} `json:"terminalInstructions"`
 }
 
-// SearchTerminalsResponse declare response for search terminals request.
-type SearchTerminalsResponse struct {
-	ItemsTotal int `json:"itemsTotal"`
-	PagesTotal int `json:"pagesTotal"`
-	Data       []struct {
-		ID                string    `json:"id"`
-		Model             string    `json:"model"`
-		SerialNumber      string    `json:"serialNumber"`
-		LastActivityAt    time.Time `json:"lastActivityAt"`
-		LastTransactionAt time.Time `json:"lastTransactionAt"`
-		FirmwareVersion   string    `json:"firmwareVersion"`
-		Assignment        struct {
-			CompanyID  string `json:"companyId"`
+// Terminal declare one terminal in the search terminals response.
+type Terminal struct {
+	ID                string    `json:"id"`
+	Model             string    `json:"model"`
+	SerialNumber      string    `json:"serialNumber"`
+	LastActivityAt    time.Time `json:"lastActivityAt"`
+	LastTransactionAt time.Time `json:"lastTransactionAt"`
+	FirmwareVersion   string    `json:"firmwareVersion"`
+	Assignment        struct {
+		CompanyID          string `json:"companyId"`
+		MerchantID         string `json:"merchantId"`
+		StoreID            string `json:"storeId"`
+		Status             string `json:"status"`
+		ReassignmentTarget struct {
 			MerchantID string `json:"merchantId"`
 			StoreID    string `json:"storeId"`
-			Status     string `json:"status"`
-		} `json:"assignment"`
-		Connectivity struct {
-			Cellular struct {
-				Status string `json:"status"`
-				Iccid  string `json:"iccid"`
-			} `json:"cellular"`
-			Wifi struct {
-				IPAddress  string `json:"ipAddress"`
-				MACAddress string `json:"macAddress"`
-			} `json:"wifi"`
-		} `json:"connectivity"`
-	} `json:"data"`
+			Inventory  bool   `json:"inventory"`
+		} `json:"reassignmentTarget"`
+	} `json:"assignment"`
+	Connectivity struct {
+		Cellular struct {
+			Status string `json:"status"`
+			Iccid  string `json:"iccid"`
+		} `json:"cellular"`
+		Wifi struct {
+			IPAddress  string `json:"ipAddress"`
+			MACAddress string `json:"macAddress"`
+		} `json:"wifi"`
+	} `json:"connectivity"`
+}
+
+// TerminalsFilter declare filter for search terminals request, empty lists mean no filter.
+type TerminalsFilter struct {
+	MerchantIDs []string
+	StoreIDs    []string
+	BrandModels []string
+	SearchQuery string
+}
+
+// SearchTerminalsResponse declare response for search terminals request.
+type SearchTerminalsResponse struct {
+	ItemsTotal int        `json:"itemsTotal"`
+	PagesTotal int        `json:"pagesTotal"`
+	Data       []Terminal `json:"data"`
+}
+
+// Android app statuses.
+
+const (
+	AppStatusProcessing = "processing"
+	AppStatusReady      = "ready"
+	AppStatusError      = "error"
+	AppStatusInvalid    = "invalid"
+	AppStatusArchived   = "archived"
+)
+
+// AndroidApp declare one android app version of the company.
+type AndroidApp struct {
+	ID          string `json:"id"`
+	PackageName string `json:"packageName"`
+	VersionCode int    `json:"versionCode"`
+	Description string `json:"description"`
+	Label       string `json:"label"`
+	VersionName string `json:"versionName"`
+	Status      string `json:"status"`
+	ErrorCode   string `json:"errorCode"`
 }
 
 // SearchAndroidAppsResponse declare response for get android apps request.
 type SearchAndroidAppsResponse struct {
+	ItemsTotal int          `json:"itemsTotal"`
+	PagesTotal int          `json:"pagesTotal"`
+	Data       []AndroidApp `json:"data"`
+}
+
+// UploadResponse declare response for upload android app and certificate requests.
+type UploadResponse struct {
+	ID string `json:"id"`
+}
+
+// Schedule action types.
+
+const (
+	ActionInstallAndroidApp           = "InstallAndroidApp"
+	ActionUninstallAndroidApp         = "UninstallAndroidApp"
+	ActionInstallAndroidCertificate   = "InstallAndroidCertificate"
+	ActionUninstallAndroidCertificate = "UninstallAndroidCertificate"
+)
+
+// ActionDetails declare the action to schedule: the app for app actions, the certificate for certificate actions.
+type ActionDetails struct {
+	Type          string `json:"type"`
+	AppID         string `json:"appId,omitempty"`
+	CertificateID string `json:"certificateId,omitempty"`
+}
+
+// SearchAndroidCertificatesResponse declare response for get android certificates request.
+type SearchAndroidCertificatesResponse struct {
 	Data []struct {
 		ID          string `json:"id"`
-		PackageName string `json:"packageName"`
-		VersionCode int    `json:"versionCode"`
+		Name        string `json:"name"`
 		Description string `json:"description"`
-		Label       string `json:"label"`
-		VersionName string `json:"versionName"`
+		Extension   string `json:"extension"`
 		Status      string `json:"status"`
+		NotBefore   string `json:"notBefore"`
+		NotAfter    string `json:"notAfter"`
 	} `json:"data"`
 }
 
 // ScheduleActionRequest declare structure for schedule action request.
 type ScheduleActionRequest struct {
-	TerminalIDs   []string `json:"terminalIds"`
-	StoreID       string   `json:"storeId"`
-	ScheduledAt   string   `json:"scheduledAt"`
-	ActionDetails struct {
-		Type  string `json:"type"`
-		AppID string `json:"appId"`
-	} `json:"actionDetails"`
+	TerminalIDs   []string      `json:"terminalIds"`
+	StoreID       string        `json:"storeId,omitempty"`
+	ScheduledAt   string        `json:"scheduledAt"`
+	ActionDetails ActionDetails `json:"actionDetails"`
 }
 
 // ScheduleActionResponse declare response for schedule action request.
 type ScheduleActionResponse struct {
-	ActionDetails struct {
-		AppID string `json:"appId"`
-		Type  string `json:"type"`
-	} `json:"actionDetails"`
-	ScheduledAt string `json:"scheduledAt"`
-	StoreID     string `json:"storeId"`
-	Items       []struct {
+	ActionDetails ActionDetails `json:"actionDetails"`
+	ScheduledAt   string        `json:"scheduledAt"`
+	StoreID       string        `json:"storeId"`
+	Items         []struct {
 		ID         string `json:"id"`
 		TerminalID string `json:"terminalId"`
 	} `json:"items"`
-	TerminalsWithErrors struct {
-	} `json:"terminalsWithErrors"`
-	TotalScheduled int `json:"totalScheduled"`
-	TotalErrors    int `json:"totalErrors"`
+	TerminalsWithErrors map[string][]string `json:"terminalsWithErrors"`
+	TotalScheduled      int                 `json:"totalScheduled"`
+	TotalErrors         int                 `json:"totalErrors"`
+}
+
+// Terminal action statuses.
+
+const (
+	ActionStatusPending    = "pending"
+	ActionStatusTryLater   = "tryLater"
+	ActionStatusSuccessful = "successful"
+	ActionStatusFailed     = "failed"
+	ActionStatusCancelled  = "cancelled"
+)
+
+// TerminalAction declare one scheduled action on one terminal.
+type TerminalAction struct {
+	ID          string `json:"id"`
+	ActionType  string `json:"actionType"`
+	Config      string `json:"config"`
+	ConfirmedAt string `json:"confirmedAt"`
+	ScheduledAt string `json:"scheduledAt"`
+	Status      string `json:"status"`
+	Result      string `json:"result"`
+	TerminalID  string `json:"terminalId"`
 }
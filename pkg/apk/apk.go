@@ -0,0 +1,263 @@
+package apk
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf16"
+)
+
+// manifestFile declare the path of the binary manifest inside APK.
+const manifestFile = "AndroidManifest.xml"
+
+// Binary XML chunk types.
+const (
+	chunkXML          = 0x0003
+	chunkStringPool   = 0x0001
+	chunkResourceMap  = 0x0180
+	chunkStartElement = 0x0102
+)
+
+// Android resource IDs of manifest attributes, used if attribute names are stripped.
+const (
+	resourceVersionCode = 0x0101021b
+	resourceVersionName = 0x0101021c
+)
+
+// Typed value types.
+const (
+	typeString  = 0x03
+	typeIntDec  = 0x10
+	typeIntHex  = 0x11
+	noRawString = 0xffffffff
+)
+
+// utf8Flag declare the string pool flag of UTF-8 strings.
+const utf8Flag = 1 << 8
+
+// ErrInvalidManifest means the binary manifest can't be parsed.
+var ErrInvalidManifest = errors.New("invalid android manifest")
+
+// Manifest declare the app identity from the manifest.
+type Manifest struct {
+	PackageName string
+	VersionCode int
+	VersionName string
+}
+
+// Parse reads the manifest of APK file.
+func Parse(path string) (*Manifest, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open apk: %w", err)
+	}
+	defer archive.Close()
+
+	file, err := archive.Open(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	buf, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return parseManifest(buf)
+}
+
+// parseManifest parses Android binary XML till the manifest element and reads its attributes.
+func parseManifest(buf []byte) (*Manifest, error) {
+	if len(buf) < 8 || binary.LittleEndian.Uint16(buf) != chunkXML {
+		return nil, ErrInvalidManifest
+	}
+
+	var pool []string
+	var resources []uint32
+	offset := int(binary.LittleEndian.Uint16(buf[2:]))
+	for offset+8 <= len(buf) {
+		chunkType := binary.LittleEndian.Uint16(buf[offset:])
+		chunkSize := int(binary.LittleEndian.Uint32(buf[offset+4:]))
+		if chunkSize < 8 || offset+chunkSize > len(buf) {
+			return nil, ErrInvalidManifest
+		}
+		chunk := buf[offset : offset+chunkSize]
+
+		switch chunkType {
+		case chunkStringPool:
+			parsed, err := parseStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			pool = parsed
+		case chunkResourceMap:
+			headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+			for i := headerSize; i+4 <= len(chunk); i += 4 {
+				resources = append(resources, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case chunkStartElement:
+			return parseManifestElement(chunk, pool, resources)
+		}
+		offset += chunkSize
+	}
+	return nil, ErrInvalidManifest
+}
+
+// parseManifestElement reads package, versionCode and versionName attributes of the first (manifest) element.
+func parseManifestElement(chunk []byte, pool []string, resources []uint32) (*Manifest, error) {
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	if len(chunk) < headerSize+20 {
+		return nil, ErrInvalidManifest
+	}
+	ext := chunk[headerSize:]
+	attributeStart := int(binary.LittleEndian.Uint16(ext[8:]))
+	attributeSize := int(binary.LittleEndian.Uint16(ext[10:]))
+	attributeCount := int(binary.LittleEndian.Uint16(ext[12:]))
+	if attributeSize < 20 || headerSize+attributeStart+attributeCount*attributeSize > len(chunk) {
+		return nil, ErrInvalidManifest
+	}
+
+	var manifest Manifest
+	for i := 0; i < attributeCount; i++ {
+		attribute := ext[attributeStart+i*attributeSize:]
+		nameIndex := binary.LittleEndian.Uint32(attribute[4:])
+		name := stringAt(pool, nameIndex)
+		if int(nameIndex) < len(resources) {
+			switch resources[nameIndex] {
+			case resourceVersionCode:
+				name = "versionCode"
+			case resourceVersionName:
+				name = "versionName"
+			}
+		}
+
+		value := attributeValue(attribute, pool)
+		switch name {
+		case "package":
+			manifest.PackageName = value
+		case "versionCode":
+			code, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: version code %q", ErrInvalidManifest, value)
+			}
+			manifest.VersionCode = code
+		case "versionName":
+			manifest.VersionName = value
+		}
+	}
+	if manifest.PackageName == "" {
+		return nil, fmt.Errorf("%w: no package name", ErrInvalidManifest)
+	}
+	return &manifest, nil
+}
+
+// attributeValue returns the raw string value or formats the typed value.
+func attributeValue(attribute []byte, pool []string) string {
+	raw := binary.LittleEndian.Uint32(attribute[8:])
+	if raw != noRawString {
+		return stringAt(pool, raw)
+	}
+
+	dataType := attribute[15]
+	data := binary.LittleEndian.Uint32(attribute[16:])
+	switch dataType {
+	case typeString:
+		return stringAt(pool, data)
+	case typeIntDec, typeIntHex:
+		return strconv.FormatInt(int64(int32(data)), 10)
+	default:
+		return strconv.FormatUint(uint64(data), 10)
+	}
+}
+
+func stringAt(pool []string, index uint32) string {
+	if int(index) < len(pool) {
+		return pool[index]
+	}
+	return ""
+}
+
+// parseStringPool decodes all strings of the pool, UTF-8 and UTF-16 pools are supported.
+func parseStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, ErrInvalidManifest
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	flags := binary.LittleEndian.Uint32(chunk[16:])
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	if headerSize+count*4 > len(chunk) || stringsStart > len(chunk) {
+		return nil, ErrInvalidManifest
+	}
+
+	pool := make([]string, count)
+	for i := range pool {
+		offset := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+i*4:]))
+		if offset >= len(chunk) {
+			return nil, ErrInvalidManifest
+		}
+		var err error
+		if flags&utf8Flag != 0 {
+			pool[i], err = decodeUTF8(chunk[offset:])
+		} else {
+			pool[i], err = decodeUTF16(chunk[offset:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pool, nil
+}
+
+func decodeUTF8(buf []byte) (string, error) {
+	// UTF-16 length goes first, then UTF-8 length, each takes 1 or 2 bytes
+	_, n := utf8Length(buf)
+	if n == 0 {
+		return "", ErrInvalidManifest
+	}
+	length, m := utf8Length(buf[n:])
+	if m == 0 || n+m+length > len(buf) {
+		return "", ErrInvalidManifest
+	}
+	return string(buf[n+m : n+m+length]), nil
+}
+
+func utf8Length(buf []byte) (length, size int) {
+	switch {
+	case len(buf) < 1:
+		return 0, 0
+	case buf[0]&0x80 == 0:
+		return int(buf[0]), 1
+	case len(buf) < 2:
+		return 0, 0
+	default:
+		return int(buf[0]&0x7f)<<8 | int(buf[1]), 2
+	}
+}
+
+func decodeUTF16(buf []byte) (string, error) {
+	if len(buf) < 2 {
+		return "", ErrInvalidManifest
+	}
+	length := int(binary.LittleEndian.Uint16(buf))
+	start := 2
+	if length&0x8000 != 0 {
+		if len(buf) < 4 {
+			return "", ErrInvalidManifest
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(buf[2:]))
+		start = 4
+	}
+	if start+length*2 > len(buf) {
+		return "", ErrInvalidManifest
+	}
+
+	chars := make([]uint16, length)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(buf[start+i*2:])
+	}
+	return string(utf16.Decode(chars)), nil
+}
@@ -0,0 +1,293 @@
+package apk
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// attribute declare the attribute of the manifest element in the test manifest.
+type attribute struct {
+	name     uint32
+	raw      uint32
+	dataType byte
+	data     uint32
+}
+
+// manifest declare the test manifest, encoded to Android binary XML.
+type manifest struct {
+	pool       []string
+	utf8       bool
+	resources  []uint32
+	attributes []attribute
+}
+
+func TestParseManifest(t *testing.T) {
+	pool := []string{"package", "versionCode", "versionName", "com.example.app", "1.2.3", "7", "abc"}
+	tests := []struct {
+		name     string
+		manifest manifest
+		want     *Manifest
+	}{
+		{
+			name: "UTF-16 pool",
+			manifest: manifest{pool: pool, attributes: []attribute{
+				{name: 0, raw: 3, dataType: typeString, data: 3},
+				{name: 1, raw: noRawString, dataType: typeIntDec, data: 42},
+				{name: 2, raw: 4, dataType: typeString, data: 4},
+			}},
+			want: &Manifest{PackageName: "com.example.app", VersionCode: 42, VersionName: "1.2.3"},
+		},
+		{
+			name: "UTF-8 pool",
+			manifest: manifest{pool: pool, utf8: true, attributes: []attribute{
+				{name: 0, raw: 3, dataType: typeString, data: 3},
+				{name: 1, raw: noRawString, dataType: typeIntDec, data: 42},
+				{name: 2, raw: 4, dataType: typeString, data: 4},
+			}},
+			want: &Manifest{PackageName: "com.example.app", VersionCode: 42, VersionName: "1.2.3"},
+		},
+		{
+			name: "stripped attribute names",
+			manifest: manifest{
+				pool:      []string{"", "", "package", "com.example.app", "2.0"},
+				resources: []uint32{resourceVersionCode, resourceVersionName},
+				attributes: []attribute{
+					{name: 2, raw: 3, dataType: typeString, data: 3},
+					{name: 0, raw: noRawString, dataType: typeIntDec, data: 200},
+					{name: 1, raw: 4, dataType: typeString, data: 4},
+				},
+			},
+			want: &Manifest{PackageName: "com.example.app", VersionCode: 200, VersionName: "2.0"},
+		},
+		{
+			name: "hex version code",
+			manifest: manifest{pool: pool, attributes: []attribute{
+				{name: 0, raw: 3, dataType: typeString, data: 3},
+				{name: 1, raw: noRawString, dataType: typeIntHex, data: 0x10},
+			}},
+			want: &Manifest{PackageName: "com.example.app", VersionCode: 16},
+		},
+		{
+			name: "version code as string",
+			manifest: manifest{pool: pool, attributes: []attribute{
+				{name: 0, raw: 3, dataType: typeString, data: 3},
+				{name: 1, raw: noRawString, dataType: typeString, data: 5},
+			}},
+			want: &Manifest{PackageName: "com.example.app", VersionCode: 7},
+		},
+		{
+			name: "long UTF-8 string",
+			manifest: manifest{pool: []string{"package", strings.Repeat("a", 200)}, utf8: true, attributes: []attribute{
+				{name: 0, raw: 1, dataType: typeString, data: 1},
+			}},
+			want: &Manifest{PackageName: strings.Repeat("a", 200)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseManifest(tt.manifest.encode())
+			if err != nil {
+				t.Fatalf("parseManifest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseManifest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	pool := []string{"package", "versionCode", "com.example.app", "abc"}
+	valid := manifest{pool: pool, attributes: []attribute{
+		{name: 0, raw: 2, dataType: typeString, data: 2},
+	}}.encode()
+	noStart := valid[:8+stringPoolSize(valid)]
+	binary.LittleEndian.PutUint32(noStart[4:], uint32(len(noStart)))
+
+	tests := []struct {
+		name string
+		buf  []byte
+	}{
+		{name: "empty", buf: nil},
+		{name: "not binary XML", buf: []byte("<manifest package=\"com.example.app\"/>")},
+		{name: "truncated", buf: valid[:len(valid)-10]},
+		{name: "no manifest element", buf: noStart},
+		{
+			name: "no package",
+			buf: manifest{pool: pool, attributes: []attribute{
+				{name: 1, raw: noRawString, dataType: typeIntDec, data: 1},
+			}}.encode(),
+		},
+		{
+			name: "version code is not a number",
+			buf: manifest{pool: pool, attributes: []attribute{
+				{name: 0, raw: 2, dataType: typeString, data: 2},
+				{name: 1, raw: 3, dataType: typeString, data: 3},
+			}}.encode(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseManifest(tt.buf); !errors.Is(err, ErrInvalidManifest) {
+				t.Errorf("parseManifest() error = %v, want %v", err, ErrInvalidManifest)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	buf := manifest{pool: []string{"package", "com.example.app"}, attributes: []attribute{
+		{name: 0, raw: 1, dataType: typeString, data: 1},
+	}}.encode()
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		want    *Manifest
+		wantErr bool
+	}{
+		{
+			name:  "manifest",
+			files: map[string][]byte{"classes.dex": nil, manifestFile: buf},
+			want:  &Manifest{PackageName: "com.example.app"},
+		},
+		{
+			name:    "no manifest",
+			files:   map[string][]byte{"classes.dex": nil},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(writeAPK(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// encode encodes the manifest: XML header, string pool, resource map and the manifest element.
+func (m manifest) encode() []byte {
+	body := m.stringPool()
+	if len(m.resources) > 0 {
+		resources := chunk(chunkResourceMap, 8, nil)
+		for _, id := range m.resources {
+			resources = binary.LittleEndian.AppendUint32(resources, id)
+		}
+		body = append(body, finish(resources)...)
+	}
+	body = append(body, m.element()...)
+	return finish(append(chunk(chunkXML, 8, nil), body...))
+}
+
+func (m manifest) stringPool() []byte {
+	var data []byte
+	offsets := make([]byte, 0, len(m.pool)*4)
+	for _, s := range m.pool {
+		offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+		if m.utf8 {
+			data = append(appendUTF8Length(appendUTF8Length(data, len(s)), len(s)), s...)
+			data = append(data, 0)
+		} else {
+			chars := utf16.Encode([]rune(s))
+			data = binary.LittleEndian.AppendUint16(data, uint16(len(chars)))
+			for _, c := range chars {
+				data = binary.LittleEndian.AppendUint16(data, c)
+			}
+			data = binary.LittleEndian.AppendUint16(data, 0)
+		}
+	}
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	var flags uint32
+	if m.utf8 {
+		flags = utf8Flag
+	}
+	header := binary.LittleEndian.AppendUint32(nil, uint32(len(m.pool)))
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	header = binary.LittleEndian.AppendUint32(header, flags)
+	header = binary.LittleEndian.AppendUint32(header, uint32(28+len(offsets)))
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	pool := chunk(chunkStringPool, 28, header)
+	return finish(append(append(pool, offsets...), data...))
+}
+
+func (m manifest) element() []byte {
+	// line number and comment of the node header, then namespace and name of the element
+	ext := make([]byte, 16)
+	ext = binary.LittleEndian.AppendUint16(ext, 20)
+	ext = binary.LittleEndian.AppendUint16(ext, 20)
+	ext = binary.LittleEndian.AppendUint16(ext, uint16(len(m.attributes)))
+	ext = append(ext, make([]byte, 6)...)
+	for _, a := range m.attributes {
+		ext = binary.LittleEndian.AppendUint32(ext, noRawString)
+		ext = binary.LittleEndian.AppendUint32(ext, a.name)
+		ext = binary.LittleEndian.AppendUint32(ext, a.raw)
+		ext = binary.LittleEndian.AppendUint16(ext, 8)
+		ext = append(ext, 0, a.dataType)
+		ext = binary.LittleEndian.AppendUint32(ext, a.data)
+	}
+	return finish(chunk(chunkStartElement, 16, ext[:8], ext[8:]...))
+}
+
+// chunk starts the chunk with the header, the size is set by finish.
+func chunk(chunkType, headerSize uint16, header []byte, body ...byte) []byte {
+	buf := binary.LittleEndian.AppendUint16(nil, chunkType)
+	buf = binary.LittleEndian.AppendUint16(buf, headerSize)
+	buf = binary.LittleEndian.AppendUint32(buf, 0)
+	return append(append(buf, header...), body...)
+}
+
+func finish(buf []byte) []byte {
+	binary.LittleEndian.PutUint32(buf[4:], uint32(len(buf)))
+	return buf
+}
+
+func appendUTF8Length(buf []byte, length int) []byte {
+	if length < 0x80 {
+		return append(buf, byte(length))
+	}
+	return append(buf, byte(length>>8)|0x80, byte(length))
+}
+
+// stringPoolSize returns the size of the string pool, which follows the XML header.
+func stringPoolSize(buf []byte) int {
+	return int(binary.LittleEndian.Uint32(buf[12:]))
+}
+
+func writeAPK(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.apk")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,110 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	runner      *commands.Runner
+	csvFilePath string
+	tracking    schedule.Tracking
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	csvFilePath string, tracking schedule.Tracking, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		runner:      commands.NewRunner(logger, config),
+		csvFilePath: csvFilePath,
+		tracking:    tracking,
+	}
+}
+
+// Run runs parsing & checking statuses of scheduled terminal actions.
+// Statuses are checked once or polled till actions are finished, the final state is reported per terminal.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := commands.NewInput[schedule.Action](p.csvFilePath)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := input.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	var trackErr error
+	if p.tracking.Wait {
+		trackErr = schedule.Wait(ctx, p.logger, p.adyenAPI, actions, p.tracking.Timeout)
+	} else {
+		trackErr = schedule.Refresh(ctx, p.logger, p.adyenAPI, actions)
+	}
+	if p.tracking.FilePath != "" {
+		if writeErr := schedule.WriteActions(p.tracking.FilePath, actions); writeErr != nil {
+			return nil, errors.Join(trackErr, writeErr)
+		}
+	}
+
+	// Not finished actions fail their rows, but the run fails totally only, if all actions failed, see schedule.Wait
+	summary, err := commands.Process(ctx, p.runner, "terminal actions", commands.NewRecordsInput(actions), p.process)
+	if trackErr != nil && !errors.Is(err, commands.ErrInterrupted) {
+		err = trackErr
+	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to check terminal actions: %w", err)
+	}
+	return summary, nil
+}
+
+// process reports the final state of one action, not successful action is the failure.
+func (p *Processor) process(_ context.Context, action *schedule.Action) error {
+	if action.Status == adyen.ActionStatusSuccessful {
+		return nil
+	}
+	if action.Result != "" {
+		return fmt.Errorf("action %s on terminal %s is %s: %s", action.ID, action.TerminalID, action.Status, action.Result)
+	}
+	return fmt.Errorf("action %s on terminal %s is %s", action.ID, action.TerminalID, action.Status)
+}
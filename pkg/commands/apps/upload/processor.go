@@ -0,0 +1,184 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/apk"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
+)
+
+// pollInterval defines how often the status of the uploaded app is checked.
+const pollInterval = 5 * time.Second
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger    *zap.Logger
+	client    *http.Client
+	adyenAPI  *adyen.API
+	runner    *commands.Runner
+	out       io.Writer
+	companyID string
+	apkPath   string
+	timeout   time.Duration
+	dryRun    bool
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config, out io.Writer,
+	companyID, apkPath string, timeout time.Duration, production, dryRun bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	return &Processor{
+		logger:    logger,
+		client:    client,
+		adyenAPI:  adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		runner:    commands.NewRunner(logger, config),
+		out:       out,
+		companyID: companyID,
+		apkPath:   apkPath,
+		timeout:   timeout,
+		dryRun:    dryRun,
+	}
+}
+
+// Run runs APK upload to the android library of the company.
+// The manifest is parsed locally first, the app ID is printed, when Adyen finishes to process the app.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	manifest, err := apk.Parse(p.apkPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
+	}
+
+	summary, err := commands.Process(ctx, p.runner, "apps", commands.NewRecordsInput([]*apk.Manifest{manifest}), p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to upload app: %w", err)
+	}
+	return summary, nil
+}
+
+func (p *Processor) process(ctx context.Context, manifest *apk.Manifest) error {
+	logger := p.logger.
+		With(zap.String("CompanyID", p.companyID)).
+		With(zap.String("PackageName", manifest.PackageName)).
+		With(zap.String("VersionName", manifest.VersionName)).
+		With(zap.Int("VersionCode", manifest.VersionCode))
+
+	// Adyen rejects the version, which is already uploaded
+	existingID, err := p.uploaded(ctx, logger, manifest)
+	if err != nil || existingID != "" {
+		return err
+	}
+
+	logger.Info("Uploading app")
+	if p.dryRun {
+		return nil
+	}
+
+	buf, err := os.ReadFile(p.apkPath)
+	if err != nil {
+		return fmt.Errorf("failed to read apk: %w", err)
+	}
+	appID, err := p.adyenAPI.UploadAndroidApp(ctx, p.companyID, filepath.Base(p.apkPath), buf)
+	if err != nil {
+		return err
+	}
+
+	logger = logger.With(zap.String("AppID", appID))
+	logger.Info("Uploaded app, waiting till it's processed")
+	if err := p.wait(ctx, appID); err != nil {
+		return err
+	}
+	logger.Info("App is ready")
+
+	_, err = fmt.Fprintln(p.out, appID)
+	return err
+}
+
+// uploaded prints the ID of the version, which is already uploaded, and returns it.
+// The version in error or invalid status is uploaded again, the one still processing is waited for.
+func (p *Processor) uploaded(ctx context.Context, logger *zap.Logger, manifest *apk.Manifest) (string, error) {
+	apps, err := schedule.NewApps(p.adyenAPI).List(ctx, p.companyID, manifest.PackageName)
+	if err != nil {
+		return "", err
+	}
+	for i := range apps {
+		if apps[i].VersionCode != manifest.VersionCode {
+			continue
+		}
+		appLogger := logger.
+			With(zap.String("AppID", apps[i].ID)).
+			With(zap.String("Status", apps[i].Status))
+		if apps[i].Status == adyen.AppStatusError || apps[i].Status == adyen.AppStatusInvalid {
+			appLogger.Warn("App version is already uploaded, but it failed, uploading it again")
+			continue
+		}
+
+		appLogger.Warn("App version is already uploaded")
+		if apps[i].Status == adyen.AppStatusProcessing && !p.dryRun {
+			if err := p.wait(ctx, apps[i].ID); err != nil {
+				return "", err
+			}
+		}
+		_, err := fmt.Fprintln(p.out, apps[i].ID)
+		return apps[i].ID, err
+	}
+	return "", nil
+}
+
+// wait polls the app status till it's ready, failed or the timeout expires.
+func (p *Processor) wait(ctx context.Context, appID string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		app, err := p.adyenAPI.AndroidApp(ctx, p.companyID, appID)
+		if err != nil {
+			return err
+		}
+		switch app.Status {
+		case adyen.AppStatusReady:
+			return nil
+		case adyen.AppStatusError, adyen.AppStatusInvalid:
+			return fmt.Errorf("app %s is %s: %s", appID, app.Status, app.ErrorCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("app %s is still %s: %w", appID, app.Status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,10 +2,8 @@ package cellular
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gocarina/gocsv"
@@ -13,6 +11,8 @@ import (
 
 	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
 )
 
 // Processor declare implementation of the main module.
@@ -20,7 +20,10 @@ type Processor struct {
 	logger      *zap.Logger
 	client      *http.Client
 	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
 	csvFilePath string
+	selector    string
 	disable     bool
 	dryRun      bool
 }
@@ -28,7 +31,7 @@ type Processor struct {
 // New creates new instance of Processor.
 func New(
 	logger *zap.Logger, client *http.Client, config *commands.Config,
-	csvFilePath string, disable, production, dryRun bool) *Processor {
+	csvFilePath, selector string, disable, production, dryRun bool) *Processor {
 	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
 	switch {
 	case production:
@@ -53,68 +56,49 @@ func New(
 
 	gocsv.SetHeaderNormalizer(strings.ToUpper)
 
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
 	return &Processor{
 		logger:      logger,
 		client:      client,
-		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
 		csvFilePath: csvFilePath,
+		selector:    selector,
 		disable:     disable,
 		dryRun:      dryRun,
 	}
 }
 
 // Run runs parsing & cellular processing.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := fleet.NewInput(ctx, p.adyenAPI, p.resolver, p.csvFilePath, p.selector,
+		func(terminal *adyen.Terminal) *Record {
+			return &Record{Serial: terminal.SerialNumber, TerminalID: terminal.ID}
+		})
 	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
-	}
-
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
-		}
-	}
+	p.resolver.PrefetchTerminals(ctx, input.Total())
+	defer p.resolver.Save()
 
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process terminals")
-		return fmt.Errorf("failed to process cellular: %w", errors.Join(errs...))
+	summary, err := commands.Process(ctx, p.runner, "terminals", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to process cellular: %w", err)
 	}
-
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process terminals")
-	return nil
+	return summary, nil
 }
 
 func (p *Processor) process(ctx context.Context, record *Record) error {
 	terminalID := record.TerminalID
 	if terminalID == "" && record.Serial != "" {
 		// Get terminal ID by serial number
-		terminals, err := p.adyenAPI.SearchTerminals(ctx, "", record.Serial)
+		id, err := p.resolver.TerminalID(ctx, record.Serial)
 		if err != nil {
-			return fmt.Errorf("failed to process terminals: %w", err)
-		}
-		if terminals.ItemsTotal != 1 {
-			return fmt.Errorf("expected 1 terminal, got %d", terminals.ItemsTotal)
+			return fmt.Errorf("failed to resolve terminal: %w", err)
 		}
-		terminalID = terminals.Data[0].ID
+		terminalID = id
 	}
 	if terminalID == "" {
 		return fmt.Errorf("no terminal id and serial number defined")
@@ -0,0 +1,153 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
+	csvFilePath string
+	selector    string
+	selected    Record
+	tracking    schedule.Tracking
+	uninstall   bool
+	dryRun      bool
+
+	mu      sync.Mutex
+	actions []*schedule.Action
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	csvFilePath, selector string, selected Record, tracking schedule.Tracking, uninstall, production, dryRun bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
+		csvFilePath: csvFilePath,
+		selector:    selector,
+		selected:    selected,
+		tracking:    tracking,
+		uninstall:   uninstall,
+		dryRun:      dryRun,
+	}
+}
+
+// Run runs parsing & certificate installation or uninstallation.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := p.input(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.resolver.PrefetchStores(ctx, input.Total())
+	defer p.resolver.Save()
+
+	entity := "certificate installations"
+	if p.uninstall {
+		entity = "certificate uninstallations"
+	}
+	summary, err := commands.Process(ctx, p.runner, entity, input, p.process)
+	if trackErr := schedule.Track(ctx, p.logger, p.adyenAPI, p.actions, &p.tracking); trackErr != nil && err == nil {
+		err = trackErr
+	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to process %s: %w", entity, err)
+	}
+	return summary, nil
+}
+
+// input returns records from CSV, or records for terminals matching the selector.
+// The certificate and the date of selected terminals are the same for all of them.
+func (p *Processor) input(ctx context.Context) (*commands.Input[Record], error) {
+	if p.selector != "" && p.csvFilePath == "" && p.selected.CertificateName == "" {
+		return nil, fmt.Errorf("%w: no certificate name defined for selected terminals", commands.ErrInvalidInput)
+	}
+	return fleet.NewInput(ctx, p.adyenAPI, p.resolver, p.csvFilePath, p.selector,
+		func(terminal *adyen.Terminal) *Record {
+			record := p.selected
+			record.CompanyID = terminal.Assignment.CompanyID
+			record.TerminalID = terminal.ID
+			return &record
+		})
+}
+
+func (p *Processor) process(ctx context.Context, record *Record) error {
+	terminalIDs, err := schedule.Terminals(ctx, p.adyenAPI, p.resolver, record.StoreID, record.TerminalFilter, record.TerminalID)
+	if err != nil {
+		return fmt.Errorf("failed to get terminals: %w", err)
+	}
+	certificateID, err := schedule.CertificateID(ctx, p.adyenAPI, record.CompanyID, record.CertificateName)
+	if err != nil {
+		return fmt.Errorf("failed to get certificate id: %w", err)
+	}
+
+	at, err := schedule.At(record.Date, nil)
+	if err != nil {
+		return err
+	}
+
+	if p.dryRun {
+		return nil
+	}
+
+	details := adyen.ActionDetails{Type: adyen.ActionInstallAndroidCertificate, CertificateID: certificateID}
+	if p.uninstall {
+		details.Type = adyen.ActionUninstallAndroidCertificate
+	}
+	actions, err := schedule.Schedule(
+		ctx, p.logger, p.adyenAPI, record.CompanyID, details, "", terminalIDs, at)
+	p.mu.Lock()
+	p.actions = append(p.actions, actions...)
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to process certificate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package certificates
+
+// Record declare one certificate install or uninstall record.
+type Record struct {
+	CompanyID       string `csv:"COMPANY ID"`
+	StoreID         string `csv:"STORE ID"`
+	TerminalFilter  string `csv:"FILTER"`
+	TerminalID      string `csv:"TERMINAL ID"`
+	CertificateName string `csv:"CERTIFICATE NAME"`
+	Date            string `csv:"DATE"`
+}
@@ -0,0 +1,151 @@
+package upload
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger          *zap.Logger
+	client          *http.Client
+	adyenAPI        *adyen.API
+	runner          *commands.Runner
+	out             io.Writer
+	companyID       string
+	certificatePath string
+	dryRun          bool
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config, out io.Writer,
+	companyID, certificatePath string, production, dryRun bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	return &Processor{
+		logger:          logger,
+		client:          client,
+		adyenAPI:        adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		runner:          commands.NewRunner(logger, config),
+		out:             out,
+		companyID:       companyID,
+		certificatePath: certificatePath,
+		dryRun:          dryRun,
+	}
+}
+
+// Run runs certificate upload to the android library of the company.
+// The certificate is parsed locally first, the certificate ID is printed, when it's uploaded.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	buf, err := os.ReadFile(p.certificatePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read certificate: %w", commands.ErrInvalidInput, err)
+	}
+	certificate, err := parseCertificate(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
+	}
+
+	summary, err := commands.Process(ctx, p.runner, "certificates", commands.NewRecordsInput([]*x509.Certificate{certificate}),
+		func(ctx context.Context, certificate *x509.Certificate) error {
+			return p.process(ctx, certificate, buf)
+		})
+	if err != nil {
+		return summary, fmt.Errorf("failed to upload certificate: %w", err)
+	}
+	return summary, nil
+}
+
+func (p *Processor) process(ctx context.Context, certificate *x509.Certificate, buf []byte) error {
+	name := filepath.Base(p.certificatePath)
+	logger := p.logger.
+		With(zap.String("CompanyID", p.companyID)).
+		With(zap.String("Name", name)).
+		With(zap.String("Subject", certificate.Subject.String())).
+		With(zap.Time("NotAfter", certificate.NotAfter))
+	if time.Now().After(certificate.NotAfter) {
+		return fmt.Errorf("certificate %s expired at %s", name, certificate.NotAfter.Format(time.RFC3339))
+	}
+
+	certificates, err := p.adyenAPI.SearchAndroidCertificates(ctx, p.companyID, name)
+	if err != nil {
+		return err
+	}
+	for i := range certificates.Data {
+		if certificates.Data[i].Name != name {
+			continue
+		}
+		certificateLogger := logger.
+			With(zap.String("CertificateID", certificates.Data[i].ID)).
+			With(zap.String("Status", certificates.Data[i].Status))
+		// Certificates share statuses with apps
+		if certificates.Data[i].Status == adyen.AppStatusError || certificates.Data[i].Status == adyen.AppStatusInvalid {
+			certificateLogger.Warn("Certificate is already uploaded, but it failed, uploading it again")
+			continue
+		}
+		certificateLogger.Warn("Certificate is already uploaded")
+		_, err := fmt.Fprintln(p.out, certificates.Data[i].ID)
+		return err
+	}
+
+	logger.Info("Uploading certificate")
+	if p.dryRun {
+		return nil
+	}
+
+	certificateID, err := p.adyenAPI.UploadAndroidCertificate(ctx, p.companyID, name, buf)
+	if err != nil {
+		return err
+	}
+	logger.
+		With(zap.String("CertificateID", certificateID)).
+		Info("Uploaded certificate")
+
+	_, err = fmt.Fprintln(p.out, certificateID)
+	return err
+}
+
+// parseCertificate parses PEM or DER encoded certificate.
+func parseCertificate(buf []byte) (*x509.Certificate, error) {
+	if block, _ := pem.Decode(buf); block != nil {
+		buf = block.Bytes
+	}
+	certificate, err := x509.ParseCertificate(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return certificate, nil
+}
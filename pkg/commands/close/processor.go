@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gocarina/gocsv"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
 )
 
 var (
@@ -25,6 +25,8 @@ type Processor struct {
 	logger           *zap.Logger
 	client           *http.Client
 	adyenAPI         *adyen.API
+	resolver         *resolver.Resolver
+	runner           *commands.Runner
 	csvFilePath      string
 	shouldCloseStore bool
 	dryRun           bool
@@ -58,10 +60,13 @@ func New(
 
 	gocsv.SetHeaderNormalizer(strings.ToUpper)
 
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
 	return &Processor{
 		logger:           logger,
 		client:           client,
-		adyenAPI:         adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		adyenAPI:         adyenAPI,
+		resolver:         resolver.New(logger, adyenAPI, config, production),
+		runner:           commands.NewRunner(logger, config),
 		csvFilePath:      csvFilePath,
 		shouldCloseStore: shouldCloseStore,
 		dryRun:           dryRun,
@@ -69,43 +74,22 @@ func New(
 }
 
 // Run runs closer of merchant accounts and stores.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := commands.NewInput[Record](p.csvFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
+	if p.shouldCloseStore {
+		p.resolver.PrefetchStores(ctx, input.Total())
 	}
+	defer p.resolver.Save()
 
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
-		}
-	}
-
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process restaurants")
-		return fmt.Errorf("failed to process close record: %w", errors.Join(errs...))
+	summary, err := commands.Process(ctx, p.runner, "restaurants", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to process close record: %w", err)
 	}
-
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process restaurants")
-	return nil
+	return summary, nil
 }
 
 func (p *Processor) process(ctx context.Context, record *Record) error {
@@ -144,21 +128,15 @@ func (p *Processor) checkAccountHolder(accountHolder *adyen.GetAccountHolderResp
 }
 
 func (p *Processor) closeStore(ctx context.Context, storeID string) error {
-	stores, err := p.adyenAPI.SearchStores(ctx, storeID)
+	store, err := p.resolver.Store(ctx, storeID)
 	if err != nil {
-		return fmt.Errorf("failed to get all stores: %w", err)
-	}
-	if stores.ItemsTotal != 1 || len(stores.Data) != 1 {
-		return ErrInvalidResponse
-	}
-	if stores.Data[0].Reference != storeID {
-		return fmt.Errorf("store ID not found: %s %s", stores.Data[0].Reference, storeID)
+		return fmt.Errorf("failed to resolve store: %w", err)
 	}
 
-	if err := p.adyenAPI.SetStoreStatus(ctx, stores.Data[0].ID, "inactive"); err != nil {
+	if err := p.adyenAPI.SetStoreStatus(ctx, store.ID, "inactive"); err != nil {
 		return fmt.Errorf("failed to set inactive status: %w", err)
 	}
-	if err := p.adyenAPI.SetStoreStatus(ctx, stores.Data[0].ID, "closed"); err != nil {
+	if err := p.adyenAPI.SetStoreStatus(ctx, store.ID, "closed"); err != nil {
 		return fmt.Errorf("failed to set closed status: %w", err)
 	}
 
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"os"
+	"sync"
+)
+
+// clearLine moves the cursor to the line start and erases the line.
+const clearLine = "\r\033[K"
+
+// Console declare the interactive terminal, which shows the live status line below the logs.
+// It's used as the log sink too, so logs don't break the status line.
+type Console struct {
+	mu     sync.Mutex
+	out    *os.File
+	status string
+}
+
+// NewConsole creates new instance of Console, if both stdout and stderr are terminals.
+// Returns nil otherwise.
+func NewConsole() *Console {
+	if !isTerminal(os.Stdout) || !isTerminal(os.Stderr) {
+		return nil
+	}
+	return &Console{out: os.Stderr}
+}
+
+// Write writes the log line above the status line.
+func (c *Console) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status != "" {
+		_, _ = c.out.WriteString(clearLine)
+	}
+	n, err := c.out.Write(p)
+	if c.status != "" {
+		_, _ = c.out.WriteString(c.status)
+	}
+	return n, err
+}
+
+// Sync flushes the console.
+func (c *Console) Sync() error {
+	return nil
+}
+
+// SetStatus replaces the status line.
+func (c *Console) SetStatus(status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, _ = c.out.WriteString(clearLine + status)
+	c.status = status
+}
+
+// ClearStatus removes the status line.
+func (c *Console) ClearStatus() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status != "" {
+		_, _ = c.out.WriteString(clearLine)
+		c.status = ""
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
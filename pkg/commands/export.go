@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+)
+
+// Export formats.
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Export fetches all entities and writes them to the file as CSV or JSON.
+// Records are written only if the whole list is fetched, the partial export is never left behind.
+func Export[T any](
+	ctx context.Context, runner *Runner, entity, path, format string, fetch func(context.Context) ([]T, error),
+) (*Summary, error) {
+	if format != FormatCSV && format != FormatJSON {
+		return nil, fmt.Errorf("%w: unsupported export format: %s", ErrInvalidInput, format)
+	}
+
+	summary := newSummary(0, false)
+	records, err := fetch(ctx)
+	if err != nil {
+		summary.finish()
+		if ctx.Err() != nil {
+			summary.Interrupted = true
+			return summary, fmt.Errorf("%w: %s not exported", ErrInterrupted, entity)
+		}
+		return summary, fmt.Errorf("%w: failed to fetch %s: %w", ErrTotalFailure, entity, err)
+	}
+
+	summary.Total = len(records)
+	if err := writeExport(path, format, records); err != nil {
+		summary.Failure = summary.Total
+		summary.finish()
+		return summary, fmt.Errorf("%w: %w", ErrTotalFailure, err)
+	}
+	summary.Success = summary.Total
+	summary.finish()
+
+	runner.logger.
+		With(zap.Int("Count", summary.Total)).
+		With(zap.String("File", path)).
+		Info("Finished to export " + entity)
+	return summary, nil
+}
+
+// writeExport writes records to the temporary file first and renames it, when it's complete.
+func writeExport[T any](path, format string, records []T) (err error) {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	switch format {
+	case FormatCSV:
+		err = gocsv.Marshal(&records, file)
+	case FormatJSON:
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(records)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	return nil
+}
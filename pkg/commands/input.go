@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gocarina/gocsv"
+)
+
+// Input declare CSV file with the records of type T.
+// Records are decoded one by one and never loaded into memory all together.
+// Input can be created from the records in memory too, if they are not read from CSV.
+type Input[T any] struct {
+	path    string
+	records []*T
+	total   int
+}
+
+// RowUnmarshaler declare the record with the columns, which are not known in advance,
+// like the setting paths. Such records are decoded from the raw header and row, not by the struct tags.
+type RowUnmarshaler interface {
+	UnmarshalRow(header, row []string) error
+}
+
+// NewInput validates the whole CSV file and counts its records.
+func NewInput[T any](path string) (*Input[T], error) {
+	input := &Input[T]{path: path}
+	if err := input.each(func(*T) bool {
+		input.total++
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+// NewRecordsInput creates the input from the records in memory.
+func NewRecordsInput[T any](records []*T) *Input[T] {
+	return &Input[T]{
+		records: records,
+		total:   len(records),
+	}
+}
+
+// Total returns the number of records in the file.
+func (i *Input[T]) Total() int {
+	return i.total
+}
+
+// Records decodes all records, use it for short lists only.
+func (i *Input[T]) Records() ([]*T, error) {
+	records := make([]*T, 0, i.total)
+	if err := i.each(func(record *T) bool {
+		records = append(records, record)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// each decodes records one by one and passes them to yield, until it returns false.
+func (i *Input[T]) each(yield func(*T) bool) error {
+	if i.path == "" {
+		for _, record := range i.records {
+			if !yield(record) {
+				break
+			}
+		}
+		return nil
+	}
+
+	file, err := os.OpenFile(i.path, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("%w: failed to open CSV: %w", ErrInvalidInput, err)
+	}
+	defer file.Close()
+
+	if _, ok := any(new(T)).(RowUnmarshaler); ok {
+		return eachRow(file, yield)
+	}
+
+	records := make(chan *T)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- gocsv.UnmarshalToChan(file, records)
+	}()
+
+	stopped := false
+	for record := range records {
+		if stopped {
+			continue
+		}
+		if !yield(record) {
+			// Closed file breaks the decoder, we only need to drain the channel.
+			stopped = true
+			_ = file.Close()
+		}
+	}
+
+	if err := <-errs; err != nil && !stopped {
+		return fmt.Errorf("%w: failed to read CSV: %w", ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// eachRow decodes records, which implement RowUnmarshaler, one by one and passes them to yield, until it returns false.
+func eachRow[T any](file io.Reader, yield func(*T) bool) error {
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%w: failed to read CSV header: %w", ErrInvalidInput, err)
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: failed to read CSV: %w", ErrInvalidInput, err)
+		}
+
+		record := new(T)
+		if err := any(record).(RowUnmarshaler).UnmarshalRow(header, row); err != nil {
+			return fmt.Errorf("%w: failed to read CSV: %w", ErrInvalidInput, err)
+		}
+		if !yield(record) {
+			return nil
+		}
+	}
+}
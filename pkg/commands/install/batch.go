@@ -0,0 +1,134 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
+)
+
+// row declare the resolved CSV row: the app and terminals to install it on, and the result of scheduling.
+type row struct {
+	number         int
+	companyID      string
+	storeReference string
+	storeID        string
+	date           string
+	timezone       string
+	terminalIDs    []string
+	appID          string
+	at             string
+	err            error
+}
+
+// batchKey declare rows, which can be scheduled together: the same app at the same time.
+// Rows, which target the whole store, are scheduled on the store level.
+type batchKey struct {
+	companyID string
+	appID     string
+	at        string
+	storeID   string
+}
+
+// batch declare terminals of one or more rows, scheduled with as few requests as possible.
+type batch struct {
+	terminalIDs []string
+	rows        map[string][]*row
+}
+
+// batches groups resolved rows, the order of terminals is kept.
+func batches(rows []*row) ([]batchKey, map[batchKey]*batch) {
+	var keys []batchKey
+	grouped := make(map[batchKey]*batch)
+	for _, r := range rows {
+		if r.err != nil {
+			continue
+		}
+
+		key := batchKey{companyID: r.companyID, appID: r.appID, at: r.at, storeID: r.storeID}
+		b, ok := grouped[key]
+		if !ok {
+			b = &batch{rows: make(map[string][]*row)}
+			grouped[key] = b
+			keys = append(keys, key)
+		}
+		for _, terminalID := range r.terminalIDs {
+			if _, ok := b.rows[terminalID]; !ok {
+				b.terminalIDs = append(b.terminalIDs, terminalID)
+			}
+			b.rows[terminalID] = append(b.rows[terminalID], r)
+		}
+	}
+	return keys, grouped
+}
+
+// scheduleBatches schedules installations of resolved rows in batches and maps results back to rows.
+// Terminals, which Adyen failed to schedule the installation on, fail their rows, errors are returned by row numbers.
+func (p *Processor) scheduleBatches(ctx context.Context) map[int]error {
+	keys, grouped := batches(p.rows)
+	failed := make(map[*row][]string)
+	reasons := make(map[*row]error)
+	for _, key := range keys {
+		b := grouped[key]
+		for _, chunk := range schedule.Chunks(b.terminalIDs) {
+			notScheduled, err := p.scheduleChunk(ctx, key, b, chunk)
+			for _, terminalID := range notScheduled {
+				for _, r := range b.rows[terminalID] {
+					failed[r] = append(failed[r], terminalID)
+					if reasons[r] == nil {
+						reasons[r] = err
+					}
+				}
+			}
+		}
+	}
+
+	errs := make(map[int]error, len(failed))
+	for r, terminalIDs := range failed {
+		r.err = fmt.Errorf("failed to schedule installation on %d of %d terminals: %s",
+			len(terminalIDs), len(r.terminalIDs), strings.Join(terminalIDs, ", "))
+		if reasons[r] != nil {
+			r.err = fmt.Errorf("%w: %w", r.err, reasons[r])
+		}
+		errs[r.number] = r.err
+	}
+	return errs
+}
+
+// scheduleChunk schedules the installation on terminals of the batch, actions are mapped to the first row of the terminal.
+// Returns terminals, which Adyen failed to schedule the installation on.
+func (p *Processor) scheduleChunk(ctx context.Context, key batchKey, b *batch, terminalIDs []string) ([]string, error) {
+	p.logger.
+		With(zap.String("CompanyID", key.companyID)).
+		With(zap.String("AppID", key.appID)).
+		With(zap.String("StoreID", key.storeID)).
+		With(zap.String("ScheduledAt", key.at)).
+		With(zap.Int("Terminals", len(terminalIDs))).
+		Info("Scheduling installations")
+	if p.dryRun {
+		return nil, nil
+	}
+
+	details := adyen.ActionDetails{Type: adyen.ActionInstallAndroidApp, AppID: key.appID}
+	actions, err := schedule.Schedule(ctx, p.logger, p.adyenAPI, key.companyID, details, key.storeID, terminalIDs, key.at)
+	scheduled := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		scheduled[action.TerminalID] = true
+		if rows := b.rows[action.TerminalID]; len(rows) > 0 {
+			action.Row = rows[0].number
+		}
+		p.actions = append(p.actions, action)
+	}
+
+	var notScheduled []string
+	for _, terminalID := range terminalIDs {
+		if !scheduled[terminalID] {
+			notScheduled = append(notScheduled, terminalID)
+		}
+	}
+	return notScheduled, err
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -14,15 +13,10 @@ import (
 
 	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
-)
-
-var (
-	// ErrInvalidResponse means we have a wrong Adyen response.
-	ErrInvalidResponse = errors.New("store details count not equal to accounts count")
-	// ErrTooManyTerminals means we have more than 100 terminals per store.
-	ErrTooManyTerminals = errors.New("too many terminals assigned to the store")
-	// ErrNoAppFound means we could not find the relevant app version.
-	ErrNoAppFound = errors.New("no app found")
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/rollout"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
 )
 
 // Processor declare implementation of the main module.
@@ -30,14 +24,27 @@ type Processor struct {
 	logger      *zap.Logger
 	client      *http.Client
 	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	apps        *schedule.Apps
+	timezones   *schedule.Timezones
+	runner      *commands.Runner
 	csvFilePath string
+	selector    string
+	selected    Record
+	tracking    schedule.Tracking
+	plan        rollout.Options
+	localTime   bool
 	dryRun      bool
+
+	rows    []*row
+	actions []*schedule.Action
 }
 
 // New creates new instance of Processor.
 func New(
 	logger *zap.Logger, client *http.Client, config *commands.Config,
-	csvFilePath string, production, dryRun bool) *Processor {
+	csvFilePath, selector string, selected Record, tracking schedule.Tracking, plan rollout.Options,
+	localTime, production, dryRun bool) *Processor {
 	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
 	switch {
 	case production:
@@ -62,139 +69,216 @@ func New(
 
 	gocsv.SetHeaderNormalizer(strings.ToUpper)
 
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	storeResolver := resolver.New(logger, adyenAPI, config, production)
 	return &Processor{
 		logger:      logger,
 		client:      client,
-		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		adyenAPI:    adyenAPI,
+		resolver:    storeResolver,
+		apps:        schedule.NewApps(adyenAPI),
+		timezones:   schedule.NewTimezones(adyenAPI, storeResolver),
+		runner:      commands.NewRunner(logger, config),
 		csvFilePath: csvFilePath,
+		selector:    selector,
+		selected:    selected,
+		tracking:    tracking,
+		plan:        plan,
+		localTime:   localTime,
 		dryRun:      dryRun,
 	}
 }
 
 // Run runs parsing & app installation.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
-	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
+// Rows are resolved first, then installations are scheduled in batches: rows with the same app and date together.
+// If the rollout state file is defined, the app is installed in waves, see runRollout.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if p.plan.StatePath != "" {
+		return p.runRollout(ctx)
 	}
-	defer file.Close()
 
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
+	input, err := p.input(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
-		}
-	}
+	p.resolver.PrefetchStores(ctx, input.Total())
+	defer p.resolver.Save()
 
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process installations")
-		return fmt.Errorf("failed to process installations: %w", errors.Join(errs...))
+	// Rows are resolved one by one, then scheduled together, results of scheduling are reported per row
+	summary, err := commands.ProcessBatched(ctx, p.runner, "installations", input, p.process, p.scheduleBatches)
+	if trackErr := schedule.Track(ctx, p.logger, p.adyenAPI, p.actions, &p.tracking); trackErr != nil && err == nil {
+		err = trackErr
 	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to process installations: %w", err)
+	}
+	return summary, nil
+}
 
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process installations")
-	return nil
+// input returns records from CSV, or records for terminals matching the selector.
+// The app and the date of selected terminals are the same for all of them.
+func (p *Processor) input(ctx context.Context) (*commands.Input[Record], error) {
+	if p.selector != "" && p.csvFilePath == "" && p.selected.PackageName == "" {
+		return nil, fmt.Errorf("%w: no package name defined for selected terminals", commands.ErrInvalidInput)
+	}
+	return fleet.NewInput(ctx, p.adyenAPI, p.resolver, p.csvFilePath, p.selector,
+		func(terminal *adyen.Terminal) *Record {
+			record := p.selected
+			record.CompanyID = terminal.Assignment.CompanyID
+			record.TerminalID = terminal.ID
+			return &record
+		})
 }
 
+// process resolves the row: terminals, the app and the date to schedule the installation at.
 func (p *Processor) process(ctx context.Context, record *Record) error {
-	var terminalIDs []string
-	if record.StoreID != "" {
-		// Need to convert Adyen Store GUID to the management ID.
-		storeID, err := p.searchStore(ctx, record.StoreID)
-		if err != nil {
-			return fmt.Errorf("failed to get store ID by UUID: %w", err)
-		}
+	r := &row{number: len(p.rows) + 1, companyID: record.CompanyID, storeReference: record.StoreID, date: record.Date}
+	p.rows = append(p.rows, r)
+	r.err = p.resolve(ctx, record, r)
+	return r.err
+}
 
-		ids, err := p.searchTerminals(ctx, storeID, record.TerminalFilter)
-		if err != nil {
-			return fmt.Errorf("failed to get terminals: %w", err)
+func (p *Processor) resolve(ctx context.Context, record *Record, r *row) error {
+	terminalIDs, err := schedule.Terminals(ctx, p.adyenAPI, p.resolver, record.StoreID, record.TerminalFilter, record.TerminalID)
+	if err != nil {
+		return fmt.Errorf("failed to get terminals: %w", err)
+	}
+	r.terminalIDs = terminalIDs
+
+	// The whole store is targeted, schedule on the store level
+	if record.StoreID != "" && record.TerminalFilter == "" {
+		var store *adyen.GetStoreResponse
+		if store, err = p.resolver.Store(ctx, record.StoreID); err != nil {
+			return fmt.Errorf("failed to get store ID by UUID: %w", err)
 		}
-		terminalIDs = ids
-	} else {
-		terminalIDs = []string{record.TerminalID}
+		r.storeID = store.ID
 	}
-	appID, err := p.appID(ctx, record.CompanyID, record.PackageName, record.VersionName)
+
+	r.appID, err = p.apps.ID(ctx, record.CompanyID, record.PackageName, record.VersionName)
 	if err != nil {
 		return fmt.Errorf("failed to get app id: %w", err)
 	}
 
-	if p.dryRun {
-		return nil
+	location, err := p.location(ctx, record)
+	if err != nil {
+		return err
 	}
+	if location != nil {
+		r.timezone = location.String()
+	}
+	r.at, err = schedule.At(record.Date, location)
+	return err
+}
 
-	scheduledAt := record.Date
-	if scheduledAt == "" {
-		scheduledAt = time.Now().Add(2 * time.Minute).Format(time.RFC3339)
-		if scheduledAt[len(scheduledAt)-1] == 'Z' {
-			scheduledAt = strings.Replace(scheduledAt, "Z", "+0000", 1)
-		}
-		if scheduledAt[len(scheduledAt)-3] == ':' {
-			scheduledAt = scheduledAt[:len(scheduledAt)-3] + scheduledAt[len(scheduledAt)-2:]
-		}
-		scheduledAt = strings.Replace(scheduledAt, "Z", "", 1)
+// location returns the location of local dates: nil for UTC,
+// or the timezone of the store or the terminal, if the local time is requested.
+func (p *Processor) location(ctx context.Context, record *Record) (*time.Location, error) {
+	if !p.localTime || record.Date == "" {
+		return nil, nil
 	}
 
-	if err := p.adyenAPI.InstallAndroidApp(ctx, appID, "", terminalIDs, scheduledAt); err != nil {
-		return fmt.Errorf("failed to process app installations: %w", err)
+	var location *time.Location
+	var err error
+	if record.StoreID != "" {
+		location, err = p.timezones.Store(ctx, record.StoreID)
+	} else {
+		location, err = p.timezones.Terminal(ctx, record.TerminalID)
 	}
-	return nil
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timezone: %w", err)
+	}
+	return location, nil
 }
 
-func (p *Processor) searchStore(ctx context.Context, storeID string) (string, error) {
-	stores, err := p.adyenAPI.SearchStores(ctx, storeID)
+// runRollout resumes the rollout from the state file, or plans the new one from CSV, and runs it wave by wave.
+func (p *Processor) runRollout(ctx context.Context) (*commands.Summary, error) {
+	state, err := rollout.Load(p.plan.StatePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to get all stores: %w", err)
+		return nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
 	}
-	if stores.ItemsTotal != 1 || len(stores.Data) != 1 {
-		return "", ErrInvalidResponse
+
+	var summary *commands.Summary
+	if state == nil {
+		summary, state, err = p.planRollout(ctx)
+		if err != nil {
+			return summary, err
+		}
+	} else {
+		p.logger.
+			With(zap.String("State", p.plan.StatePath)).
+			With(zap.String("Status", state.Status)).
+			With(zap.Int("Wave", state.Wave+1)).
+			With(zap.Int("Waves", len(state.Waves))).
+			Info("Resuming rollout")
 	}
-	if stores.Data[0].Reference != storeID {
-		return "", fmt.Errorf("store ID not found: %s %s", stores.Data[0].Reference, storeID)
+	if p.dryRun {
+		return summary, nil
 	}
-	return stores.Data[0].ID, nil
-}
 
-func (p *Processor) searchTerminals(ctx context.Context, storeID, searchQuery string) ([]string, error) {
-	terminals, err := p.adyenAPI.SearchTerminals(ctx, storeID, searchQuery)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get terminals: %w", err)
+	actions, err := rollout.Run(ctx, p.logger, p.adyenAPI, state, p.tracking.Timeout)
+	if p.tracking.FilePath != "" {
+		if writeErr := schedule.WriteActions(p.tracking.FilePath, actions); writeErr != nil {
+			err = errors.Join(err, writeErr)
+		}
 	}
-	if terminals.PagesTotal > 1 {
-		return nil, ErrTooManyTerminals
+	if err != nil {
+		return summary, fmt.Errorf("failed to roll out installations: %w", err)
 	}
+	return summary, nil
+}
 
-	terminalIDs := make([]string, 0, len(terminals.Data))
-	for i := range terminals.Data {
-		terminalIDs = append(terminalIDs, terminals.Data[i].ID)
+// planRollout resolves terminals and apps of all records and splits terminals into waves.
+// The rollout is not started, if any record failed.
+func (p *Processor) planRollout(ctx context.Context) (*commands.Summary, *rollout.State, error) {
+	input, err := p.input(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
-	return terminalIDs, nil
-}
 
-func (p *Processor) appID(ctx context.Context, companyID, packageName, versionName string) (string, error) {
-	apps, err := p.adyenAPI.SearchAndroidApps(ctx, companyID, packageName)
+	p.resolver.PrefetchStores(ctx, input.Total())
+	defer p.resolver.Save()
+
+	summary, err := commands.Process(ctx, p.runner, "installations", input, p.process)
+	if err != nil {
+		return summary, nil, fmt.Errorf("failed to plan rollout: %w", err)
+	}
+	state, err := rollout.Plan(p.plan.StatePath, p.plan.Waves, p.targets(), p.plan.FailureThreshold)
 	if err != nil {
-		return "", fmt.Errorf("failed to get all apps: %w", err)
+		return summary, nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
 	}
 
-	for i := range apps.Data {
-		if apps.Data[i].VersionName == versionName {
-			return apps.Data[i].ID, nil
+	for i, wave := range state.Waves {
+		p.logger.
+			With(zap.Int("Wave", i+1)).
+			With(zap.String("Spec", wave.Spec)).
+			With(zap.Int("Terminals", len(wave.Targets))).
+			Info("Planned rollout wave")
+	}
+	if p.dryRun {
+		return summary, state, nil
+	}
+	if err := state.Save(); err != nil {
+		return summary, nil, err
+	}
+	return summary, state, nil
+}
+
+// targets returns terminals of resolved rows to roll out the app on.
+// Dates are kept as they are defined and resolved, when their wave starts.
+func (p *Processor) targets() []rollout.Target {
+	var targets []rollout.Target
+	for _, r := range p.rows {
+		for _, terminalID := range r.terminalIDs {
+			targets = append(targets, rollout.Target{
+				CompanyID:  r.companyID,
+				StoreID:    r.storeReference,
+				TerminalID: terminalID,
+				AppID:      r.appID,
+				Date:       r.date,
+				Timezone:   r.timezone,
+			})
 		}
 	}
-	return "", ErrNoAppFound
+	return targets
 }
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/AlekSi/pointer"
@@ -26,6 +25,7 @@ type Processor struct {
 	logger      *zap.Logger
 	client      *http.Client
 	adyenAPI    *adyen.API
+	runner      *commands.Runner
 	csvFilePath string
 	balance     bool
 	dryRun      bool
@@ -63,6 +63,7 @@ func New(
 		logger:      logger,
 		client:      client,
 		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		runner:      commands.NewRunner(logger, config),
 		balance:     balance,
 		csvFilePath: csvFilePath,
 		dryRun:      dryRun,
@@ -70,43 +71,17 @@ func New(
 }
 
 // Run runs parsing & split config updating.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := commands.NewInput[Record](p.csvFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
-	}
-
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
-		}
-	}
-
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process restaurants")
-		return fmt.Errorf("failed to process link record: %w", errors.Join(errs...))
+	summary, err := commands.Process(ctx, p.runner, "restaurants", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to process link record: %w", err)
 	}
-
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process restaurants")
-	return nil
+	return summary, nil
 }
 
 func (p *Processor) process(ctx context.Context, record *Record) error {
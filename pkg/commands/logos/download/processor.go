@@ -0,0 +1,200 @@
+package download
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/logo"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+// manifestFile declare the name of CSV file with downloaded logos, it's written to the output directory.
+const manifestFile = "logos.csv"
+
+// unsafeChars declare characters, which are replaced in file names.
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
+	level       string
+	csvFilePath string
+	model       string
+	outDirPath  string
+
+	mu      sync.Mutex
+	records []Record
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	level, csvFilePath, model, outDirPath string, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
+		level:       level,
+		csvFilePath: csvFilePath,
+		model:       model,
+		outDirPath:  outDirPath,
+	}
+}
+
+// Run runs parsing & logos downloading.
+// Every logo is written to the output directory together with CSV, which can be used to upload logos back.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if err := fleet.ValidateLevel(p.level); err != nil {
+		return nil, err
+	}
+	input, err := commands.NewInput[Record](p.csvFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(p.outDirPath, 0o750); err != nil {
+		return nil, fmt.Errorf("%w: failed to create output directory: %w", commands.ErrInvalidInput, err)
+	}
+
+	switch p.level {
+	case adyen.LevelTerminal:
+		p.resolver.PrefetchTerminals(ctx, input.Total())
+	case adyen.LevelStore:
+		p.resolver.PrefetchStores(ctx, input.Total())
+	}
+	defer p.resolver.Save()
+
+	summary, err := commands.Process(ctx, p.runner, p.level+" logos", input, p.process)
+	if writeErr := p.write(); writeErr != nil {
+		return summary, fmt.Errorf("failed to download logos: %w", writeErr)
+	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to download logos: %w", err)
+	}
+	return summary, nil
+}
+
+func (p *Processor) process(ctx context.Context, record *Record) error {
+	target, err := fleet.Resolve(ctx, p.resolver, p.level, &fleet.Record{
+		CompanyID:  record.CompanyID,
+		MerchantID: record.MerchantID,
+		StoreID:    record.StoreID,
+		Serial:     record.Serial,
+		TerminalID: record.TerminalID,
+	})
+	if err != nil {
+		return err
+	}
+
+	model := record.Model
+	if model == "" {
+		model = p.model
+	}
+	// Upload requires the model, so the manifest gets the model of the terminal itself
+	if model == "" && p.level == adyen.LevelTerminal {
+		if model, err = p.resolver.TerminalModel(ctx, target.ID); err != nil {
+			return fmt.Errorf("failed to resolve terminal model: %w", err)
+		}
+	}
+	if model == "" {
+		return fmt.Errorf("no terminal model defined")
+	}
+
+	data, err := p.adyenAPI.TerminalLogo(ctx, target.SettingsTarget, model)
+	if err != nil {
+		return fmt.Errorf("failed to download logo: %w", err)
+	}
+	if data == "" {
+		p.logger.
+			With(zap.String("Level", target.Level)).
+			With(zap.String("ID", target.ID)).
+			With(zap.String("Model", model)).
+			Info("No terminal logo defined")
+		return nil
+	}
+	buf, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode logo: %w", err)
+	}
+
+	fileName := p.fileName(&target, model) + logo.Extension(buf)
+	if err := os.WriteFile(filepath.Join(p.outDirPath, fileName), buf, 0o600); err != nil {
+		return fmt.Errorf("failed to write logo: %w", err)
+	}
+
+	downloaded := *record
+	downloaded.Model = model
+	downloaded.Logo = fileName
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = append(p.records, downloaded)
+	return nil
+}
+
+// fileName returns the logo file name without extension, like store-REF1-S1F2.
+func (p *Processor) fileName(target *fleet.Target, model string) string {
+	parts := []string{target.Level, target.Name}
+	if target.Name == "" {
+		parts[1] = target.ID
+	}
+	if model != "" {
+		parts = append(parts, model)
+	}
+	return unsafeChars.ReplaceAllString(strings.Join(parts, "-"), "_")
+}
+
+// write writes CSV with downloaded logos to the output directory.
+func (p *Processor) write() error {
+	file, err := os.Create(filepath.Join(p.outDirPath, manifestFile))
+	if err != nil {
+		return fmt.Errorf("failed to create logos file: %w", err)
+	}
+	defer file.Close()
+	if err := gocsv.MarshalFile(&p.records, file); err != nil {
+		return fmt.Errorf("failed to write logos file: %w", err)
+	}
+	return nil
+}
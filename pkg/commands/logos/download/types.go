@@ -0,0 +1,13 @@
+package download
+
+// Record declare one downloaded logo: the owner of the logo, depending on the level, the terminal model and the image.
+// Downloaded records can be used as the input of logos upload.
+type Record struct {
+	CompanyID  string `csv:"COMPANY ID"`
+	MerchantID string `csv:"MERCHANT ID"`
+	StoreID    string `csv:"STORE ID"`
+	Serial     string `csv:"SERIAL"`
+	TerminalID string `csv:"TERMINAL ID"`
+	Model      string `csv:"MODEL"`
+	Logo       string `csv:"LOGO"`
+}
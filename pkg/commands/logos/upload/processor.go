@@ -0,0 +1,162 @@
+package upload
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/logo"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
+	level       string
+	csvFilePath string
+	logoPath    string
+	model       string
+	dryRun      bool
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	level, csvFilePath, logoPath, model string, production, dryRun bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
+		level:       level,
+		csvFilePath: csvFilePath,
+		logoPath:    logoPath,
+		model:       model,
+		dryRun:      dryRun,
+	}
+}
+
+// Run runs parsing & logos uploading.
+// Logos are uploaded on the level: per company, merchant or store and the terminal model, or per terminal.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if err := fleet.ValidateLevel(p.level); err != nil {
+		return nil, err
+	}
+	input, err := commands.NewInput[Record](p.csvFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.level {
+	case adyen.LevelTerminal:
+		p.resolver.PrefetchTerminals(ctx, input.Total())
+	case adyen.LevelStore:
+		p.resolver.PrefetchStores(ctx, input.Total())
+	}
+	defer p.resolver.Save()
+
+	summary, err := commands.Process(ctx, p.runner, p.level+" logos", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to upload logos: %w", err)
+	}
+	return summary, nil
+}
+
+func (p *Processor) process(ctx context.Context, record *Record) error {
+	target, err := fleet.Resolve(ctx, p.resolver, p.level, &fleet.Record{
+		CompanyID:  record.CompanyID,
+		MerchantID: record.MerchantID,
+		StoreID:    record.StoreID,
+		Serial:     record.Serial,
+		TerminalID: record.TerminalID,
+	})
+	if err != nil {
+		return err
+	}
+
+	model, logoPath := p.logo(record)
+	if model == "" || logoPath == "" {
+		return fmt.Errorf("no terminal model or logo defined")
+	}
+
+	buf, err := os.ReadFile(logoPath)
+	if err != nil {
+		return fmt.Errorf("failed to read logo: %w", err)
+	}
+	if err := logo.Validate(model, buf); err != nil {
+		return fmt.Errorf("invalid logo %s: %w", logoPath, err)
+	}
+
+	p.logger.
+		With(zap.String("Level", target.Level)).
+		With(zap.String("ID", target.ID)).
+		With(zap.String("Name", target.Name)).
+		With(zap.String("Model", model)).
+		With(zap.String("Logo", logoPath)).
+		Info("Uploading terminal logo")
+	if p.dryRun {
+		return nil
+	}
+	if err := p.adyenAPI.UpdateTerminalLogo(
+		ctx, target.SettingsTarget, model, base64.StdEncoding.EncodeToString(buf)); err != nil {
+		return fmt.Errorf("failed to upload logo: %w", err)
+	}
+	return nil
+}
+
+// logo returns the terminal model and the path to the logo of the record, flags are used as defaults.
+// Relative paths in CSV are relative to CSV itself, so the downloaded backup can be uploaded from anywhere.
+func (p *Processor) logo(record *Record) (model, logoPath string) {
+	model = record.Model
+	if model == "" {
+		model = p.model
+	}
+	logoPath = record.Logo
+	switch {
+	case logoPath == "":
+		logoPath = p.logoPath
+	case !filepath.IsAbs(logoPath):
+		logoPath = filepath.Join(filepath.Dir(p.csvFilePath), logoPath)
+	}
+	return model, logoPath
+}
@@ -0,0 +1,12 @@
+package upload
+
+// Record declare one logo record: the owner of the logo, depending on the level, the terminal model and the image.
+type Record struct {
+	CompanyID  string `csv:"COMPANY ID"`
+	MerchantID string `csv:"MERCHANT ID"`
+	StoreID    string `csv:"STORE ID"`
+	Serial     string `csv:"SERIAL"`
+	TerminalID string `csv:"TERMINAL ID"`
+	Model      string `csv:"MODEL"`
+	Logo       string `csv:"LOGO"`
+}
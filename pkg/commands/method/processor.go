@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gocarina/gocsv"
@@ -13,11 +12,7 @@ import (
 
 	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
-)
-
-var (
-	// ErrInvalidResponse means we have a wrong Adyen response.
-	ErrInvalidResponse = errors.New("store details count not equal to accounts count")
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
 )
 
 // Processor declare implementation of the main module.
@@ -25,6 +20,8 @@ type Processor struct {
 	logger           *zap.Logger
 	client           *http.Client
 	adyenAPI         *adyen.API
+	resolver         *resolver.Resolver
+	runner           *commands.Runner
 	csvFilePath      string
 	shouldCloseStore bool
 	dryRun           bool
@@ -58,72 +55,46 @@ func New(
 
 	gocsv.SetHeaderNormalizer(strings.ToUpper)
 
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
 	return &Processor{
 		logger:      logger,
 		client:      client,
-		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
 		csvFilePath: csvFilePath,
 		dryRun:      dryRun,
 	}
 }
 
 // Run runs add payment methods to stores.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := commands.NewInput[Record](p.csvFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
-	}
-	defer file.Close()
-
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
+		return nil, err
 	}
 
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
-		}
-	}
+	p.resolver.PrefetchStores(ctx, input.Total())
+	defer p.resolver.Save()
 
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process restaurants")
-		return fmt.Errorf("failed to process add payment methods record: %w", errors.Join(errs...))
+	summary, err := commands.Process(ctx, p.runner, "restaurants", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to process add payment methods record: %w", err)
 	}
-
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process restaurants")
-	return nil
+	return summary, nil
 }
 
 func (p *Processor) process(ctx context.Context, record *Record) error {
-	stores, err := p.adyenAPI.SearchStores(ctx, record.StoreID)
+	store, err := p.resolver.Store(ctx, record.StoreID)
 	if err != nil {
-		return fmt.Errorf("failed to get all stores: %w", err)
-	}
-	if stores.ItemsTotal != 1 || len(stores.Data) != 1 {
-		return ErrInvalidResponse
-	}
-	if stores.Data[0].Reference != record.StoreID {
-		return fmt.Errorf("store ID not found: %s %s", stores.Data[0].Reference, record.StoreID)
+		return fmt.Errorf("failed to resolve store: %w", err)
 	}
-	if len(stores.Data[0].BusinessLineIDs) != 1 {
-		return fmt.Errorf("store does not have one business line: %d", len(stores.Data[0].BusinessLineIDs))
+	if len(store.BusinessLineIDs) != 1 {
+		return fmt.Errorf("store does not have one business line: %d", len(store.BusinessLineIDs))
 	}
 
 	if !p.dryRun {
-		if err := p.addPaymentMethods(ctx, &stores.Data[0], record.PaymentMethods, record.Currency); err != nil {
+		if err := p.addPaymentMethods(ctx, store, record.PaymentMethods, record.Currency); err != nil {
 			return fmt.Errorf("failed to add payment methods: %w", err)
 		}
 	}
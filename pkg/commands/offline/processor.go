@@ -2,10 +2,8 @@ package offline
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gocarina/gocsv"
@@ -13,6 +11,8 @@ import (
 
 	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
 )
 
 // Processor declare implementation of the main module.
@@ -20,14 +20,18 @@ type Processor struct {
 	logger      *zap.Logger
 	client      *http.Client
 	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
+	level       string
 	csvFilePath string
+	selector    string
 	dryRun      bool
 }
 
 // New creates new instance of Processor.
 func New(
 	logger *zap.Logger, client *http.Client, config *commands.Config,
-	csvFilePath string, production, dryRun bool) *Processor {
+	level, csvFilePath, selector string, production, dryRun bool) *Processor {
 	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
 	switch {
 	case production:
@@ -52,74 +56,66 @@ func New(
 
 	gocsv.SetHeaderNormalizer(strings.ToUpper)
 
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
 	return &Processor{
 		logger:      logger,
 		client:      client,
-		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
+		level:       level,
 		csvFilePath: csvFilePath,
+		selector:    selector,
 		dryRun:      dryRun,
 	}
 }
 
 // Run runs parsing & offline payments processing.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
-	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
+// Offline payments are disabled on the level: once per company, merchant or store, or per terminal.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if err := fleet.ValidateLevel(p.level); err != nil {
+		return nil, err
 	}
-	defer file.Close()
-
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
+	if p.selector != "" && p.level != adyen.LevelTerminal {
+		return nil, fmt.Errorf("%w: terminals can be selected on the terminal level only", commands.ErrInvalidInput)
 	}
-
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
-		}
+	input, err := fleet.NewInput(ctx, p.adyenAPI, p.resolver, p.csvFilePath, p.selector,
+		func(terminal *adyen.Terminal) *Record {
+			return &Record{Serial: terminal.SerialNumber, TerminalID: terminal.ID}
+		})
+	if err != nil {
+		return nil, err
 	}
 
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process terminals")
-		return fmt.Errorf("failed to process offline payments: %w", errors.Join(errs...))
+	switch p.level {
+	case adyen.LevelTerminal:
+		p.resolver.PrefetchTerminals(ctx, input.Total())
+	case adyen.LevelStore:
+		p.resolver.PrefetchStores(ctx, input.Total())
 	}
+	defer p.resolver.Save()
 
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process terminals")
-	return nil
+	summary, err := commands.Process(ctx, p.runner, p.level+" settings", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to process offline payments: %w", err)
+	}
+	return summary, nil
 }
 
 func (p *Processor) process(ctx context.Context, record *Record) error {
-	terminalID := record.TerminalID
-	if terminalID == "" && record.Serial != "" {
-		// Get terminal ID by serial number
-		terminals, err := p.adyenAPI.SearchTerminals(ctx, "", record.Serial)
-		if err != nil {
-			return fmt.Errorf("failed to process terminals: %w", err)
-		}
-		if terminals.ItemsTotal != 1 {
-			return fmt.Errorf("expected 1 terminal, got %d", terminals.ItemsTotal)
-		}
-		terminalID = terminals.Data[0].ID
-	}
-	if terminalID == "" {
-		return fmt.Errorf("no terminal id and serial number defined")
+	target, err := fleet.Resolve(ctx, p.resolver, p.level, &fleet.Record{
+		CompanyID:  record.CompanyID,
+		MerchantID: record.MerchantID,
+		StoreID:    record.StoreID,
+		Serial:     record.Serial,
+		TerminalID: record.TerminalID,
+	})
+	if err != nil {
+		return err
 	}
 
 	// Get existing terminal settings
-	settings, err := p.adyenAPI.TerminalSettings(ctx, terminalID)
+	settings, err := p.adyenAPI.TerminalSettings(ctx, target.SettingsTarget)
 	if err != nil {
 		return fmt.Errorf("failed to process settings: %w", err)
 	}
@@ -134,7 +130,7 @@ func (p *Processor) process(ctx context.Context, record *Record) error {
 	if p.dryRun {
 		return nil
 	}
-	if err := p.adyenAPI.DisableOfflinePayments(ctx, terminalID, update); err != nil {
+	if err := p.adyenAPI.DisableOfflinePayments(ctx, target.SettingsTarget, update); err != nil {
 		return fmt.Errorf("failed to process offline payments: %w", err)
 	}
 	return nil
@@ -1,7 +1,10 @@
 package offline
 
-// Record declare one cellular record.
+// Record declare one offline payments record: company, merchant, store or terminal, depending on the level.
 type Record struct {
+	CompanyID  string `csv:"COMPANY ID"`
+	MerchantID string `csv:"MERCHANT ID"`
+	StoreID    string `csv:"STORE ID"`
 	Serial     string `csv:"SERIAL"`
 	TerminalID string `csv:"TERMINAL ID"`
 }
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// progressRedrawInterval limits how often the status line is redrawn.
+	progressRedrawInterval = 200 * time.Millisecond
+	// progressLogInterval defines how often the progress is logged, if there is no console.
+	progressLogInterval = 10 * time.Second
+)
+
+// progress declare the progress of the run, it's safe to update it from several workers.
+type progress struct {
+	mu       sync.Mutex
+	logger   *zap.Logger
+	console  *Console
+	entity   string
+	total    int
+	success  int
+	failure  int
+	started  time.Time
+	reported time.Time
+}
+
+func newProgress(logger *zap.Logger, console *Console, entity string, total int) *progress {
+	now := time.Now()
+	return &progress{
+		logger:   logger,
+		console:  console,
+		entity:   entity,
+		total:    total,
+		started:  now,
+		reported: now,
+	}
+}
+
+// add counts one processed record and reports the progress, if it's time to.
+func (p *progress) add(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		p.failure++
+	} else {
+		p.success++
+	}
+
+	now := time.Now()
+	done := p.success + p.failure
+	switch {
+	case p.console != nil:
+		if now.Sub(p.reported) >= progressRedrawInterval || done == p.total {
+			p.console.SetStatus(p.status(now))
+			p.reported = now
+		}
+	case now.Sub(p.reported) >= progressLogInterval:
+		rate, eta := p.rate(now)
+		p.logger.
+			With(zap.Int("Done", done)).
+			With(zap.Int("Total", p.total)).
+			With(zap.Int("Success Count", p.success)).
+			With(zap.Int("Failure Count", p.failure)).
+			With(zap.Float64("Rate", rate)).
+			With(zap.Duration("ETA", eta)).
+			Info("Progress of " + p.entity)
+		p.reported = now
+	}
+}
+
+// finish removes the status line, the summary is logged instead.
+func (p *progress) finish() {
+	if p.console != nil {
+		p.console.ClearStatus()
+	}
+}
+
+func (p *progress) status(now time.Time) string {
+	done := p.success + p.failure
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(done) * 100 / float64(p.total)
+	}
+	rate, eta := p.rate(now)
+	return fmt.Sprintf("%s: %d/%d (%.1f%%), ok %d, failed %d, %.1f rows/s, ETA %s",
+		p.entity, done, p.total, percent, p.success, p.failure, rate, eta)
+}
+
+// rate returns rows per second and estimated time to finish.
+func (p *progress) rate(now time.Time) (float64, time.Duration) {
+	done := p.success + p.failure
+	elapsed := now.Sub(p.started).Seconds()
+	if done == 0 || elapsed <= 0 {
+		return 0, 0
+	}
+	rate := float64(done) / elapsed
+	eta := time.Duration(float64(p.total-done) / rate * float64(time.Second))
+	return rate, eta.Round(time.Second)
+}
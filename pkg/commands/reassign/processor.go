@@ -2,10 +2,8 @@ package reassign
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gocarina/gocsv"
@@ -13,11 +11,8 @@ import (
 
 	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
-)
-
-var (
-	// ErrInvalidResponse means we have a wrong Adyen response.
-	ErrInvalidResponse = errors.New("store details count not equal to accounts count")
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
 )
 
 // Processor declare implementation of the main module.
@@ -25,14 +20,20 @@ type Processor struct {
 	logger      *zap.Logger
 	client      *http.Client
 	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
 	csvFilePath string
+	selector    string
+	fromStore   string
+	merchantID  string
+	storeID     string
 	dryRun      bool
 }
 
 // New creates new instance of Processor.
 func New(
 	logger *zap.Logger, client *http.Client, config *commands.Config,
-	csvFilePath string, production, dryRun bool) *Processor {
+	csvFilePath, selector, fromStore, merchantID, storeID string, production, dryRun bool) *Processor {
 	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
 	switch {
 	case production:
@@ -57,53 +58,72 @@ func New(
 
 	gocsv.SetHeaderNormalizer(strings.ToUpper)
 
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
 	return &Processor{
 		logger:      logger,
 		client:      client,
-		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
 		csvFilePath: csvFilePath,
+		selector:    selector,
+		fromStore:   fromStore,
+		merchantID:  merchantID,
+		storeID:     storeID,
 		dryRun:      dryRun,
 	}
 }
 
 // Run runs parsing & terminal re-assignment.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
+// Terminals moved from one store to another are re-read after the re-assignment to verify they moved,
+// the terminal, which didn't move, fails its row.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	query, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	input, err := fleet.NewInput(ctx, p.adyenAPI, p.resolver, p.csvFilePath, query,
+		func(terminal *adyen.Terminal) *Record {
+			return &Record{
+				Serial:     terminal.SerialNumber,
+				TerminalID: terminal.ID,
+				MerchantID: p.merchantID,
+				StoreID:    p.storeID,
+			}
+		})
 	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
+	p.resolver.PrefetchStores(ctx, input.Total())
+	p.resolver.PrefetchTerminals(ctx, input.Total())
+	defer p.resolver.Save()
+
+	summary, err := commands.Process(ctx, p.runner, "terminals", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to process re-assignment: %w", err)
 	}
+	return summary, nil
+}
 
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
+// query returns the selector of terminals, all terminals of the store are selected, if the store to move from is defined.
+func (p *Processor) query() (string, error) {
+	if p.fromStore == "" {
+		if p.selector != "" && p.merchantID == "" && p.storeID == "" {
+			return "", fmt.Errorf("%w: no merchant or store to move terminals to defined, use --to-merchant or --to-store with --select",
+				commands.ErrInvalidInput)
 		}
+		return p.selector, nil
 	}
-
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process terminals")
-		return fmt.Errorf("failed to process re-assignment: %w", errors.Join(errs...))
+	if p.storeID == "" {
+		return "", fmt.Errorf("%w: no store to move terminals to defined, use --to-store with --from-store",
+			commands.ErrInvalidInput)
 	}
-
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process terminals")
-	return nil
+	if strings.EqualFold(p.storeID, p.fromStore) {
+		return "", fmt.Errorf("%w: the store to move terminals to must differ from the store to move from",
+			commands.ErrInvalidInput)
+	}
+	return strings.TrimSpace("store=" + p.fromStore + " " + p.selector), nil
 }
 
 func (p *Processor) process(ctx context.Context, record *Record) error {
@@ -123,21 +143,48 @@ func (p *Processor) process(ctx context.Context, record *Record) error {
 	if err := p.adyenAPI.ReassignTerminal(ctx, terminalID, record.MerchantID, storeID); err != nil {
 		return fmt.Errorf("failed to process terminal re-assignment: %w", err)
 	}
+	if p.fromStore != "" {
+		return p.verify(ctx, &move{serial: record.Serial, terminalID: terminalID, storeID: storeID})
+	}
 	return nil
 }
 
+// verify re-reads the terminal and checks it's assigned to the store, or the re-assignment to the store is in progress.
+// Boarded terminals move, when they connect to Adyen next time.
+func (p *Processor) verify(ctx context.Context, m *move) error {
+	terminals, err := p.adyenAPI.SearchTerminals(ctx, "", m.serial)
+	if err != nil {
+		return fmt.Errorf("failed to re-read terminal (%s): %w", m.serial, err)
+	}
+	for i := range terminals.Data {
+		terminal := &terminals.Data[i]
+		if terminal.ID != m.terminalID {
+			continue
+		}
+		switch {
+		case terminal.Assignment.StoreID == m.storeID:
+			return nil
+		case terminal.Assignment.ReassignmentTarget.StoreID == m.storeID:
+			p.logger.
+				With(zap.String("Serial", m.serial)).
+				With(zap.String("Status", terminal.Assignment.Status)).
+				Warn("Terminal re-assignment is in progress")
+			return nil
+		}
+		return fmt.Errorf("terminal (%s) didn't move, it's assigned to the store (%s)", m.serial, terminal.Assignment.StoreID)
+	}
+	return fmt.Errorf("terminal (%s) not found", m.serial)
+}
+
 func (p *Processor) searchTerminal(ctx context.Context, record *Record) (string, error) {
 	terminalID := record.TerminalID
 	if terminalID == "" && record.Serial != "" {
 		// Get terminal ID by serial number
-		terminals, err := p.adyenAPI.SearchTerminals(ctx, "", record.Serial)
+		id, err := p.resolver.TerminalID(ctx, record.Serial)
 		if err != nil {
-			return "", fmt.Errorf("failed to process terminals: %w", err)
-		}
-		if terminals.ItemsTotal != 1 {
-			return "", fmt.Errorf("expected 1 terminal, got %d", terminals.ItemsTotal)
+			return "", fmt.Errorf("failed to resolve terminal: %w", err)
 		}
-		terminalID = terminals.Data[0].ID
+		terminalID = id
 	}
 	if terminalID == "" {
 		return "", fmt.Errorf("no terminal id and serial number defined")
@@ -149,20 +196,15 @@ func (p *Processor) searchStore(ctx context.Context, record *Record) (string, er
 	storeID := record.StoreID
 	if storeID != "" {
 		// Need to convert Adyen Store GUID to the management ID.
-		stores, err := p.adyenAPI.SearchStores(ctx, record.StoreID)
+		store, err := p.resolver.Store(ctx, record.StoreID)
 		if err != nil {
-			return "", fmt.Errorf("failed to get all stores: %w", err)
-		}
-		if stores.ItemsTotal != 1 || len(stores.Data) != 1 {
-			return "", ErrInvalidResponse
-		}
-		if stores.Data[0].Reference != record.StoreID {
-			return "", fmt.Errorf("store ID not found: %s %s", stores.Data[0].Reference, record.StoreID)
+			return "", fmt.Errorf("failed to resolve store: %w", err)
 		}
-		storeID = stores.Data[0].ID
+		storeID = store.ID
 	}
-	if storeID == "" {
-		return "", fmt.Errorf("no store id defined")
+	// Terminals without the store are assigned to the merchant inventory
+	if storeID == "" && record.MerchantID == "" {
+		return "", fmt.Errorf("no merchant id and store id defined")
 	}
 	return storeID, nil
 }
@@ -7,3 +7,10 @@ type Record struct {
 	MerchantID string `csv:"MERCHANT ID"`
 	StoreID    string `csv:"STORE ID"`
 }
+
+// move declare the terminal, which was re-assigned to the store and should be verified.
+type move struct {
+	serial     string
+	terminalID string
+	storeID    string
+}
@@ -0,0 +1,40 @@
+package abort
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/rollout"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger    *zap.Logger
+	statePath string
+}
+
+// New creates new instance of Processor.
+func New(logger *zap.Logger, statePath string) *Processor {
+	return &Processor{
+		logger:    logger,
+		statePath: statePath,
+	}
+}
+
+// Run runs the rollout aborting: no more waves are scheduled, even if the rollout is resumed.
+func (p *Processor) Run(_ context.Context) (*commands.Summary, error) {
+	state, err := rollout.Abort(p.statePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to abort rollout: %w", commands.ErrInvalidInput, err)
+	}
+
+	p.logger.
+		With(zap.String("State", p.statePath)).
+		With(zap.Int("Wave", state.Wave+1)).
+		With(zap.Int("Waves", len(state.Waves))).
+		Info("Rollout aborted")
+	return nil, nil
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInterrupted means the processing was interrupted by the signal.
+	ErrInterrupted = errors.New("interrupted")
+	// ErrInvalidInput means the input (CSV, flags) can't be used.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrConfig means the configuration or credentials are wrong.
+	ErrConfig = errors.New("invalid configuration")
+	// ErrPartialFailure means some records failed to process.
+	ErrPartialFailure = errors.New("partial failure")
+	// ErrTotalFailure means all records failed to process.
+	ErrTotalFailure = errors.New("total failure")
+	// ErrDrift means the checked entities differ from the baseline.
+	ErrDrift = errors.New("drift detected")
+	// ErrNonCompliant means the checked entities violate the policy.
+	ErrNonCompliant = errors.New("policy violated")
+)
+
+// maxReportedErrors limits the number of errors, which are kept till the end of the run.
+const maxReportedErrors = 100
+
+// Runner declare the shared loop over the input records.
+type Runner struct {
+	logger         *zap.Logger
+	console        *Console
+	gracePeriod    time.Duration
+	recordOutcomes bool
+}
+
+// NewRunner creates new instance of Runner.
+func NewRunner(logger *zap.Logger, config *Config) *Runner {
+	return &Runner{
+		logger:         logger,
+		console:        config.Console,
+		gracePeriod:    config.GracePeriod,
+		recordOutcomes: config.RecordOutcomes,
+	}
+}
+
+// Process runs process for every record one by one, as they are decoded from the input.
+// Failures are logged as soon as they happen, the progress is shown in the status line of the console
+// or logged periodically.
+// When ctx is done, no new records are scheduled, the in-flight record gets the grace period to finish
+// and the summary is logged as usual.
+func Process[T any](
+	ctx context.Context, runner *Runner, entity string, input *Input[T], process func(context.Context, *T) error,
+) (*Summary, error) {
+	return ProcessBatched(ctx, runner, entity, input, process, nil)
+}
+
+// ProcessBatched runs process for every record the same way as Process, then runs flush once for all processed records,
+// e.g. to send them to Adyen together. Flush returns errors of rows, which failed to flush, by row numbers,
+// they fail successfully processed rows in the same summary. Flush gets the same grace period as the in-flight record.
+func ProcessBatched[T any](
+	ctx context.Context, runner *Runner, entity string, input *Input[T], process func(context.Context, *T) error,
+	flush func(context.Context) map[int]error,
+) (*Summary, error) {
+	work, cancel := withGracePeriod(ctx, runner.gracePeriod)
+	defer cancel()
+
+	summary := newSummary(input.Total(), runner.recordOutcomes)
+	progress := newProgress(runner.logger, runner.console, entity, input.Total())
+	errs := make([]error, 0, maxReportedErrors)
+	failedRows := make(map[int]bool)
+	logError := func(row int, err error) {
+		failedRows[row] = true
+		runner.logger.
+			With(zap.Int("Row", row)).
+			With(zap.Error(err)).
+			Error("Failed to process one of " + entity)
+		if len(errs) < maxReportedErrors {
+			errs = append(errs, err)
+		}
+	}
+
+	row := 0
+	err := input.each(func(record *T) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		row++
+		err := process(work, record)
+		if err != nil {
+			logError(row, err)
+		}
+		summary.add(row, err)
+		progress.add(err)
+		return true
+	})
+	progress.finish()
+	if err != nil {
+		return nil, err
+	}
+	if flush != nil {
+		failed := flush(work)
+		for failedRow := 1; failedRow <= row; failedRow++ {
+			if err := failed[failedRow]; err != nil && !failedRows[failedRow] {
+				logError(failedRow, err)
+				summary.fail(failedRow, err)
+			}
+		}
+	}
+	for row < input.Total() {
+		row++
+		summary.skip(row)
+	}
+	summary.finish()
+	return report(ctx, runner, entity, summary, errs)
+}
+
+// report logs the summary and returns the error, which classifies the run.
+func report(ctx context.Context, runner *Runner, entity string, summary *Summary, errs []error) (*Summary, error) {
+	if summary.Failure > len(errs) {
+		errs = append(errs, fmt.Errorf("%d more errors", summary.Failure-len(errs)))
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		summary.Interrupted = true
+		runner.logger.
+			With(zap.Int("Success Count", summary.Success)).
+			With(zap.Int("Failure Count", summary.Failure)).
+			With(zap.Int("Skipped Count", summary.Skipped)).
+			Warn("Interrupted to process " + entity)
+		return summary, fmt.Errorf("%w: %d %s not processed", ErrInterrupted, summary.Skipped, entity)
+	case summary.Failure > 0:
+		runner.logger.
+			With(zap.Int("Success Count", summary.Success)).
+			With(zap.Int("Failure Count", summary.Failure)).
+			Error("Failed to process " + entity)
+		if summary.Success == 0 {
+			return summary, fmt.Errorf("%w: %w", ErrTotalFailure, errors.Join(errs...))
+		}
+		return summary, fmt.Errorf("%w: %w", ErrPartialFailure, errors.Join(errs...))
+	}
+
+	runner.logger.
+		With(zap.Int("Success Count", summary.Success)).
+		Info("Finished to process " + entity)
+	return summary, nil
+}
+
+// withGracePeriod returns the context, which is not canceled together with ctx,
+// but gracePeriod later. It lets in-flight requests finish after the signal.
+func withGracePeriod(ctx context.Context, gracePeriod time.Duration) (context.Context, context.CancelFunc) {
+	work, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-work.Done():
+			return
+		}
+
+		timer := time.NewTimer(gracePeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-work.Done():
+		}
+	}()
+	return work, cancel
+}
@@ -0,0 +1,153 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+)
+
+const pageSize = 100
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger       *zap.Logger
+	client       *http.Client
+	adyenAPI     *adyen.API
+	runner       *commands.Runner
+	outFilePath  string
+	linkFilePath string
+	format       string
+	merchantIDs  []string
+
+	links []Link
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	outFilePath, linkFilePath, format string, merchantIDs []string, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	return &Processor{
+		logger:       logger,
+		client:       client,
+		adyenAPI:     adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		runner:       commands.NewRunner(logger, config),
+		outFilePath:  outFilePath,
+		linkFilePath: linkFilePath,
+		format:       format,
+		merchantIDs:  merchantIDs,
+	}
+}
+
+// Run runs export of all stores of the company or of the selected merchants.
+// Balance platform links of stores are written to the separate CSV, if requested, `link --balance` accepts it as the input.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	summary, err := commands.Export(ctx, p.runner, "stores", p.outFilePath, p.format, p.fetch)
+	if err != nil {
+		return summary, fmt.Errorf("failed to export stores: %w", err)
+	}
+	if p.linkFilePath != "" {
+		_, err = commands.Export(ctx, p.runner, "store links", p.linkFilePath, commands.FormatCSV,
+			func(context.Context) ([]Link, error) {
+				return p.links, nil
+			})
+		if err != nil {
+			return summary, fmt.Errorf("failed to export store links: %w", err)
+		}
+	}
+	return summary, nil
+}
+
+func (p *Processor) fetch(ctx context.Context) ([]Record, error) {
+	if len(p.merchantIDs) == 0 {
+		return p.fetchPages(ctx, "")
+	}
+
+	var records []Record
+	for _, merchantID := range p.merchantIDs {
+		merchantRecords, err := p.fetchPages(ctx, merchantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stores of merchant (%s): %w", merchantID, err)
+		}
+		records = append(records, merchantRecords...)
+	}
+	return records, nil
+}
+
+// fetchPages pages through the stores of the merchant, or of the whole company if no merchant defined.
+func (p *Processor) fetchPages(ctx context.Context, merchantID string) ([]Record, error) {
+	var records []Record
+	for pageNumber, pagesTotal := 1, 1; pageNumber <= pagesTotal; pageNumber++ {
+		var stores *adyen.SearchStoresResponse
+		var err error
+		if merchantID == "" {
+			stores, err = p.adyenAPI.Stores(ctx, pageNumber, pageSize)
+		} else {
+			stores, err = p.adyenAPI.MerchantStores(ctx, merchantID, pageNumber, pageSize)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range stores.Data {
+			records = append(records, newRecord(&stores.Data[i]))
+			// Stores without the balance account have nothing to link
+			if stores.Data[i].SplitConfiguration.BalanceAccountID != "" {
+				p.links = append(p.links, Link{
+					MerchantID:        stores.Data[i].MerchantID,
+					StoreID:           stores.Data[i].ID,
+					AccountHolderCode: stores.Data[i].SplitConfiguration.BalanceAccountID,
+					SplitID:           stores.Data[i].SplitConfiguration.SplitConfigurationID,
+				})
+			}
+		}
+		pagesTotal = stores.PagesTotal
+	}
+	return records, nil
+}
+
+func newRecord(store *adyen.GetStoreResponse) Record {
+	return Record{
+		ID:               store.ID,
+		StoreID:          store.Reference,
+		MerchantID:       store.MerchantID,
+		Status:           store.Status,
+		Description:      store.Description,
+		BusinessLineIDs:  strings.Join(store.BusinessLineIDs, "|"),
+		AddressLine1:     store.Address.Line1,
+		AddressLine2:     store.Address.Line2,
+		AddressLine3:     store.Address.Line3,
+		City:             store.Address.City,
+		PostalCode:       store.Address.PostalCode,
+		StateOrProvince:  store.Address.StateOrProvince,
+		Country:          store.Address.Country,
+		BalanceAccountID: store.SplitConfiguration.BalanceAccountID,
+		SplitID:          store.SplitConfiguration.SplitConfigurationID,
+	}
+}
@@ -0,0 +1,30 @@
+package stores
+
+// Record declare one exported store record.
+// 'Store ID' is the store reference, it matches the input of `close`, `methods` and `install`.
+type Record struct {
+	ID               string `csv:"ID" json:"id"`
+	StoreID          string `csv:"STORE ID" json:"reference"`
+	MerchantID       string `csv:"MERCHANT ID" json:"merchantId"`
+	Status           string `csv:"STATUS" json:"status"`
+	Description      string `csv:"DESCRIPTION" json:"description"`
+	BusinessLineIDs  string `csv:"BUSINESS LINE IDS" json:"businessLineIds"`
+	AddressLine1     string `csv:"ADDRESS LINE 1" json:"addressLine1"`
+	AddressLine2     string `csv:"ADDRESS LINE 2" json:"addressLine2"`
+	AddressLine3     string `csv:"ADDRESS LINE 3" json:"addressLine3"`
+	City             string `csv:"CITY" json:"city"`
+	PostalCode       string `csv:"POSTAL CODE" json:"postalCode"`
+	StateOrProvince  string `csv:"STATE OR PROVINCE" json:"stateOrProvince"`
+	Country          string `csv:"COUNTRY" json:"country"`
+	BalanceAccountID string `csv:"BALANCE ACCOUNT ID" json:"balanceAccountId"`
+	SplitID          string `csv:"SPLIT ID" json:"splitId"`
+}
+
+// Link declare the balance platform link of one store.
+// Column names match the input of `link --balance`: 'Store ID' is the store ID, 'Account Holder Code' the balance account.
+type Link struct {
+	MerchantID        string `csv:"MERCHANT ID" json:"merchantId"`
+	StoreID           string `csv:"STORE ID" json:"storeId"`
+	AccountHolderCode string `csv:"ACCOUNT HOLDER CODE" json:"balanceAccountId"`
+	SplitID           string `csv:"SPLIT ID" json:"splitId"`
+}
@@ -0,0 +1,78 @@
+package commands
+
+import "time"
+
+// Outcome statuses.
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeSkipped = "skipped"
+)
+
+// Outcome declare the result of one processed record.
+type Outcome struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Summary declare the result of the whole run.
+type Summary struct {
+	Command     string    `json:"command"`
+	Total       int       `json:"total"`
+	Success     int       `json:"success"`
+	Failure     int       `json:"failure"`
+	Skipped     int       `json:"skipped"`
+	Interrupted bool      `json:"interrupted"`
+	StartedAt   time.Time `json:"startedAt"`
+	DurationMs  int64     `json:"durationMs"`
+	Error       string    `json:"error,omitempty"`
+	Outcomes    []Outcome `json:"outcomes"`
+}
+
+// newSummary creates the summary, per-row outcomes are kept only if requested.
+func newSummary(total int, recordOutcomes bool) *Summary {
+	summary := &Summary{
+		Total:     total,
+		StartedAt: time.Now(),
+	}
+	if recordOutcomes {
+		summary.Outcomes = make([]Outcome, 0, total)
+	}
+	return summary
+}
+
+func (s *Summary) add(row int, err error) {
+	if err != nil {
+		s.Failure++
+		s.record(Outcome{Row: row, Status: OutcomeFailure, Error: err.Error()})
+		return
+	}
+	s.Success++
+	s.record(Outcome{Row: row, Status: OutcomeSuccess})
+}
+
+// fail turns the successful row into the failed one, outcomes are recorded in the order of rows.
+func (s *Summary) fail(row int, err error) {
+	s.Success--
+	s.Failure++
+	if s.Outcomes != nil {
+		s.Outcomes[row-1] = Outcome{Row: row, Status: OutcomeFailure, Error: err.Error()}
+	}
+}
+
+func (s *Summary) skip(row int) {
+	s.Skipped++
+	s.record(Outcome{Row: row, Status: OutcomeSkipped})
+}
+
+func (s *Summary) record(outcome Outcome) {
+	if s.Outcomes != nil {
+		s.Outcomes = append(s.Outcomes, outcome)
+	}
+}
+
+func (s *Summary) finish() {
+	s.DurationMs = time.Since(s.StartedAt).Milliseconds()
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gocarina/gocsv"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/Toshik1978/csv2adyen/pkg/adyen"
 	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
 )
 
 var (
@@ -25,6 +25,8 @@ type Processor struct {
 	logger           *zap.Logger
 	client           *http.Client
 	adyenAPI         *adyen.API
+	resolver         *resolver.Resolver
+	runner           *commands.Runner
 	csvFilePath      string
 	shouldCloseStore bool
 	dryRun           bool
@@ -58,53 +60,32 @@ func New(
 
 	gocsv.SetHeaderNormalizer(strings.ToUpper)
 
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
 	return &Processor{
 		logger:      logger,
 		client:      client,
-		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
 		csvFilePath: csvFilePath,
 		dryRun:      dryRun,
 	}
 }
 
 // Run runs fix sweep configuration.
-func (p *Processor) Run(ctx context.Context) error {
-	file, err := os.OpenFile(p.csvFilePath, os.O_RDONLY, os.ModePerm)
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := commands.NewInput[Record](p.csvFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to open CSV: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	var records []*Record
-	if err := gocsv.UnmarshalFile(file, &records); err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
-	}
-
-	var successCnt int
-	var failureCnt int
-	errs := make([]error, 0, len(records))
-	for _, record := range records {
-		if err := p.process(ctx, record); err != nil {
-			failureCnt++
-			errs = append(errs, err)
-		} else {
-			successCnt++
-		}
-	}
+	defer p.resolver.Save()
 
-	if failureCnt > 0 {
-		p.logger.
-			With(zap.Errors("Errors", errs)).
-			With(zap.Int("Success Count", successCnt)).
-			With(zap.Int("Failure Count", failureCnt)).
-			Error("Failed to process restaurants")
-		return fmt.Errorf("failed to process fix sweep configuration record: %w", errors.Join(errs...))
+	summary, err := commands.Process(ctx, p.runner, "restaurants", input, p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to process fix sweep configuration record: %w", err)
 	}
-
-	p.logger.
-		With(zap.Int("Success Count", successCnt)).
-		Info("Finished to process restaurants")
-	return nil
+	return summary, nil
 }
 
 func (p *Processor) process(ctx context.Context, record *Record) error {
@@ -159,7 +140,7 @@ func (p *Processor) ids(ctx context.Context, record *Record) (string, string, er
 		return "", "", fmt.Errorf("no balance account holder identified: %s", record.BalanceID)
 	}
 
-	acc, err := p.adyenAPI.BalanceAccountHolder(ctx, accountHolderID)
+	acc, err := p.resolver.AccountHolder(ctx, accountHolderID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get balance account by holder (%s): %w", record.AccountHolderID, err)
 	}
@@ -0,0 +1,231 @@
+package firmware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/version"
+)
+
+// anyModel declare the policy model, which applies to models without own policy.
+const anyModel = "*"
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger             *zap.Logger
+	client             *http.Client
+	adyenAPI           *adyen.API
+	resolver           *resolver.Resolver
+	runner             *commands.Runner
+	policyFilePath     string
+	outFilePath        string
+	violationsFilePath string
+	format             string
+	filter             fleet.Filter
+
+	policies   map[string]string
+	violations []Violation
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	policyFilePath, outFilePath, violationsFilePath, format string, filter fleet.Filter, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:             logger,
+		client:             client,
+		adyenAPI:           adyenAPI,
+		resolver:           resolver.New(logger, adyenAPI, config, production),
+		runner:             commands.NewRunner(logger, config),
+		policyFilePath:     policyFilePath,
+		outFilePath:        outFilePath,
+		violationsFilePath: violationsFilePath,
+		format:             format,
+		filter:             filter,
+	}
+}
+
+// Run runs the firmware compliance report: terminals grouped by model and firmware against the policy.
+// Returns commands.ErrNonCompliant, if any terminal runs the firmware below the minimum version of its model.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if err := p.loadPolicies(); err != nil {
+		return nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
+	}
+	defer p.resolver.Save()
+
+	summary, err := commands.Export(ctx, p.runner, "firmware groups", p.outFilePath, p.format, p.fetch)
+	if err != nil {
+		return summary, fmt.Errorf("failed to check firmware compliance: %w", err)
+	}
+	if p.violationsFilePath != "" {
+		_, err = commands.Export(ctx, p.runner, "firmware violations", p.violationsFilePath, p.format,
+			func(context.Context) ([]Violation, error) {
+				return p.violations, nil
+			})
+		if err != nil {
+			return summary, fmt.Errorf("failed to check firmware compliance: %w", err)
+		}
+	}
+
+	if len(p.violations) > 0 {
+		p.logger.
+			With(zap.Int("Violations Count", len(p.violations))).
+			Warn("Terminals run unsupported firmware")
+		return summary, fmt.Errorf("%w: %d terminals run unsupported firmware", commands.ErrNonCompliant, len(p.violations))
+	}
+	return summary, nil
+}
+
+// loadPolicies reads minimum firmware versions per model.
+func (p *Processor) loadPolicies() error {
+	input, err := commands.NewInput[Policy](p.policyFilePath)
+	if err != nil {
+		return err
+	}
+	records, err := input.Records()
+	if err != nil {
+		return err
+	}
+
+	p.policies = make(map[string]string, len(records))
+	for i, record := range records {
+		if record.Model == "" || record.MinimumVersion == "" {
+			return fmt.Errorf("no model or minimum version defined in the policy row %d", i+1)
+		}
+		p.policies[strings.ToUpper(record.Model)] = record.MinimumVersion
+	}
+	return nil
+}
+
+// fetch searches terminals and groups them by model and firmware.
+// Terminals of the non-compliant groups are kept as violations.
+func (p *Processor) fetch(ctx context.Context) ([]Group, error) {
+	terminals, err := fleet.Search(ctx, p.adyenAPI, p.resolver, &p.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p.resolver.PrefetchStores(ctx, len(terminals))
+	groups := make(map[[2]string]*Group)
+	for i := range terminals {
+		terminal := &terminals[i]
+		key := [2]string{terminal.Model, terminal.FirmwareVersion}
+		group, ok := groups[key]
+		if !ok {
+			group = p.newGroup(terminal)
+			groups[key] = group
+		}
+		group.Terminals++
+		if group.Compliant {
+			continue
+		}
+
+		violation, err := p.newViolation(ctx, terminal, group)
+		if err != nil {
+			return nil, err
+		}
+		p.violations = append(p.violations, violation)
+	}
+	return p.sortGroups(groups), nil
+}
+
+// newGroup checks the firmware of the terminal against the policy of its model.
+// Terminals with unknown firmware or of models without policy are not compliant.
+func (p *Processor) newGroup(terminal *adyen.Terminal) *Group {
+	minimum, ok := p.policies[strings.ToUpper(terminal.Model)]
+	if !ok {
+		minimum = p.policies[anyModel]
+	}
+	return &Group{
+		Model:           terminal.Model,
+		FirmwareVersion: terminal.FirmwareVersion,
+		MinimumVersion:  minimum,
+		Compliant: minimum != "" && terminal.FirmwareVersion != "" &&
+			version.Compare(fleet.FirmwareVersion(terminal.FirmwareVersion), fleet.FirmwareVersion(minimum)) >= 0,
+	}
+}
+
+func (p *Processor) newViolation(ctx context.Context, terminal *adyen.Terminal, group *Group) (Violation, error) {
+	violation := Violation{
+		TerminalID:      terminal.ID,
+		Serial:          terminal.SerialNumber,
+		Model:           terminal.Model,
+		FirmwareVersion: terminal.FirmwareVersion,
+		MinimumVersion:  group.MinimumVersion,
+		MerchantID:      terminal.Assignment.MerchantID,
+	}
+	if terminal.Assignment.StoreID != "" {
+		store, err := p.resolver.StoreByID(ctx, terminal.Assignment.StoreID)
+		if err != nil {
+			return violation, fmt.Errorf("failed to resolve store of terminal (%s): %w", terminal.ID, err)
+		}
+		violation.StoreID = store.Reference
+		violation.StoreDescription = store.Description
+	}
+	return violation, nil
+}
+
+// sortGroups sorts groups by model and firmware version and logs non-compliant ones.
+func (p *Processor) sortGroups(groups map[[2]string]*Group) []Group {
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Model != result[j].Model {
+			return result[i].Model < result[j].Model
+		}
+		if compared := version.Compare(
+			fleet.FirmwareVersion(result[i].FirmwareVersion), fleet.FirmwareVersion(result[j].FirmwareVersion)); compared != 0 {
+			return compared < 0
+		}
+		return result[i].FirmwareVersion < result[j].FirmwareVersion
+	})
+
+	for i := range result {
+		if !result[i].Compliant {
+			p.logger.
+				With(zap.String("Model", result[i].Model)).
+				With(zap.String("Firmware", result[i].FirmwareVersion)).
+				With(zap.String("Minimum", result[i].MinimumVersion)).
+				With(zap.Int("Count", result[i].Terminals)).
+				Warn("Firmware is not compliant")
+		}
+	}
+	return result
+}
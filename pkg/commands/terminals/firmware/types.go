@@ -0,0 +1,29 @@
+package firmware
+
+// Policy declare the minimum supported firmware version of the terminal model.
+// The model `*` applies to models, which have no own policy.
+type Policy struct {
+	Model          string `csv:"MODEL"`
+	MinimumVersion string `csv:"MINIMUM VERSION"`
+}
+
+// Group declare terminals of one model, which run the same firmware.
+type Group struct {
+	Model           string `csv:"MODEL" json:"model"`
+	FirmwareVersion string `csv:"FIRMWARE VERSION" json:"firmwareVersion"`
+	MinimumVersion  string `csv:"MINIMUM VERSION" json:"minimumVersion"`
+	Compliant       bool   `csv:"COMPLIANT" json:"compliant"`
+	Terminals       int    `csv:"TERMINALS" json:"terminals"`
+}
+
+// Violation declare one terminal, which runs the firmware below the minimum version of its model.
+type Violation struct {
+	TerminalID       string `csv:"TERMINAL ID" json:"terminalId"`
+	Serial           string `csv:"SERIAL" json:"serial"`
+	Model            string `csv:"MODEL" json:"model"`
+	FirmwareVersion  string `csv:"FIRMWARE VERSION" json:"firmwareVersion"`
+	MinimumVersion   string `csv:"MINIMUM VERSION" json:"minimumVersion"`
+	MerchantID       string `csv:"MERCHANT ID" json:"merchantId"`
+	StoreID          string `csv:"STORE ID" json:"storeReference"`
+	StoreDescription string `csv:"STORE DESCRIPTION" json:"storeDescription"`
+}
@@ -0,0 +1,130 @@
+package terminals
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
+	outFilePath string
+	format      string
+	filter      fleet.Filter
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	outFilePath, format string, filter fleet.Filter, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
+		outFilePath: outFilePath,
+		format:      format,
+		filter:      filter,
+	}
+}
+
+// Run runs export of the terminals fleet.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	defer p.resolver.Save()
+
+	summary, err := commands.Export(ctx, p.runner, "terminals", p.outFilePath, p.format, p.fetch)
+	if err != nil {
+		return summary, fmt.Errorf("failed to export terminals: %w", err)
+	}
+	return summary, nil
+}
+
+func (p *Processor) fetch(ctx context.Context) ([]Record, error) {
+	terminals, err := fleet.Search(ctx, p.adyenAPI, p.resolver, &p.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Terminals refer stores by management ID, the reference is what humans use.
+	p.resolver.PrefetchStores(ctx, len(terminals))
+	records := make([]Record, 0, len(terminals))
+	for i := range terminals {
+		record, err := p.newRecord(ctx, &terminals[i])
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (p *Processor) newRecord(ctx context.Context, terminal *adyen.Terminal) (Record, error) {
+	record := Record{
+		TerminalID:        terminal.ID,
+		Serial:            terminal.SerialNumber,
+		Model:             terminal.Model,
+		FirmwareVersion:   terminal.FirmwareVersion,
+		Status:            terminal.Assignment.Status,
+		CompanyID:         terminal.Assignment.CompanyID,
+		MerchantID:        terminal.Assignment.MerchantID,
+		LastActivityAt:    formatTime(terminal.LastActivityAt),
+		LastTransactionAt: formatTime(terminal.LastTransactionAt),
+		CellularStatus:    terminal.Connectivity.Cellular.Status,
+		ICCID:             terminal.Connectivity.Cellular.Iccid,
+		WifiIPAddress:     terminal.Connectivity.Wifi.IPAddress,
+		WifiMACAddress:    terminal.Connectivity.Wifi.MACAddress,
+	}
+	if terminal.Assignment.StoreID != "" {
+		store, err := p.resolver.StoreByID(ctx, terminal.Assignment.StoreID)
+		if err != nil {
+			return record, fmt.Errorf("failed to resolve store of terminal (%s): %w", terminal.ID, err)
+		}
+		record.StoreID = store.Reference
+		record.StoreDescription = store.Description
+	}
+	return record, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
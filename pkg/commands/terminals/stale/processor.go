@@ -0,0 +1,271 @@
+package stale
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+const day = 24 * time.Hour
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger              *zap.Logger
+	client              *http.Client
+	adyenAPI            *adyen.API
+	resolver            *resolver.Resolver
+	runner              *commands.Runner
+	outFilePath         string
+	format              string
+	filter              fleet.Filter
+	days                int
+	allowlistPath       string
+	remediationFilePath string
+	returnToInventory   bool
+	dryRun              bool
+
+	returns []*Return
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	outFilePath, format string, filter fleet.Filter, days int, allowlistPath, remediationFilePath string,
+	returnToInventory, production, dryRun bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:              logger,
+		client:              client,
+		adyenAPI:            adyenAPI,
+		resolver:            resolver.New(logger, adyenAPI, config, production),
+		runner:              commands.NewRunner(logger, config),
+		outFilePath:         outFilePath,
+		format:              format,
+		filter:              filter,
+		days:                days,
+		allowlistPath:       allowlistPath,
+		remediationFilePath: remediationFilePath,
+		returnToInventory:   returnToInventory,
+		dryRun:              dryRun,
+	}
+}
+
+// Run runs detection of stale terminals: terminals, which were not active for longer than the given number of days.
+// Stale terminals assigned to stores are written to the remediation file and returned to the merchant inventory,
+// if requested.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if p.days <= 0 {
+		return nil, fmt.Errorf("%w: the number of days must be positive", commands.ErrInvalidInput)
+	}
+	defer p.resolver.Save()
+
+	summary, err := commands.Export(ctx, p.runner, "stale terminals", p.outFilePath, p.format, p.fetch)
+	if err != nil {
+		return summary, fmt.Errorf("failed to detect stale terminals: %w", err)
+	}
+	if p.remediationFilePath != "" {
+		if err := writeReturns(p.remediationFilePath, p.returns); err != nil {
+			return summary, fmt.Errorf("failed to write remediation: %w", err)
+		}
+	}
+	if !p.returnToInventory {
+		return summary, nil
+	}
+
+	summary, err = commands.Process(ctx, p.runner, "stale terminals", commands.NewRecordsInput(p.returns), p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to return stale terminals to inventory: %w", err)
+	}
+	return summary, nil
+}
+
+// fetch searches terminals and returns stale ones, sorted by merchant and store.
+func (p *Processor) fetch(ctx context.Context) ([]Record, error) {
+	allowed, err := p.allowlist()
+	if err != nil {
+		return nil, err
+	}
+	terminals, err := fleet.Search(ctx, p.adyenAPI, p.resolver, &p.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	since := now.Add(-time.Duration(p.days) * day)
+	var records []Record
+	p.resolver.PrefetchStores(ctx, len(terminals))
+	for i := range terminals {
+		terminal := &terminals[i]
+		if allowed[terminal.ID] || allowed[terminal.SerialNumber] || !isStale(terminal, since) {
+			continue
+		}
+		record, err := p.newRecord(ctx, terminal, now)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].MerchantID != records[j].MerchantID {
+			return records[i].MerchantID < records[j].MerchantID
+		}
+		return records[i].StoreID < records[j].StoreID
+	})
+	// Terminals in the inventory already have nothing to return
+	for i := range records {
+		if records[i].StoreID != "" {
+			p.returns = append(p.returns, &Return{
+				Serial:     records[i].Serial,
+				TerminalID: records[i].TerminalID,
+				MerchantID: records[i].MerchantID,
+			})
+		}
+	}
+	p.logGroups(records)
+	return records, nil
+}
+
+// allowlist reads terminal IDs and serial numbers of terminals, which are never reported as stale.
+func (p *Processor) allowlist() (map[string]bool, error) {
+	allowed := make(map[string]bool)
+	if p.allowlistPath == "" {
+		return allowed, nil
+	}
+
+	input, err := commands.NewInput[Allowed](p.allowlistPath)
+	if err != nil {
+		return nil, err
+	}
+	records, err := input.Records()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.TerminalID != "" {
+			allowed[record.TerminalID] = true
+		}
+		if record.Serial != "" {
+			allowed[record.Serial] = true
+		}
+	}
+	return allowed, nil
+}
+
+func (p *Processor) newRecord(ctx context.Context, terminal *adyen.Terminal, now time.Time) (Record, error) {
+	record := Record{
+		MerchantID:        terminal.Assignment.MerchantID,
+		TerminalID:        terminal.ID,
+		Serial:            terminal.SerialNumber,
+		Model:             terminal.Model,
+		Status:            terminal.Assignment.Status,
+		LastActivityAt:    formatTime(terminal.LastActivityAt),
+		LastTransactionAt: formatTime(terminal.LastTransactionAt),
+	}
+	if last := lastSeen(terminal); !last.IsZero() {
+		record.InactiveDays = strconv.Itoa(int(now.Sub(last) / day))
+	}
+	if terminal.Assignment.StoreID != "" {
+		store, err := p.resolver.StoreByID(ctx, terminal.Assignment.StoreID)
+		if err != nil {
+			return record, fmt.Errorf("failed to resolve store of terminal (%s): %w", terminal.ID, err)
+		}
+		record.StoreID = store.Reference
+		record.StoreDescription = store.Description
+	}
+	return record, nil
+}
+
+// logGroups logs the number of stale terminals per merchant and store, records are sorted by them.
+func (p *Processor) logGroups(records []Record) {
+	for i := 0; i < len(records); {
+		j := i + 1
+		for j < len(records) && records[j].MerchantID == records[i].MerchantID && records[j].StoreID == records[i].StoreID {
+			j++
+		}
+		p.logger.
+			With(zap.String("MerchantID", records[i].MerchantID)).
+			With(zap.String("StoreID", records[i].StoreID)).
+			With(zap.Int("Count", j-i)).
+			Warn("Stale terminals found")
+		i = j
+	}
+}
+
+func (p *Processor) process(ctx context.Context, record *Return) error {
+	if p.dryRun {
+		return nil
+	}
+	if err := p.adyenAPI.ReassignTerminal(ctx, record.TerminalID, record.MerchantID, ""); err != nil {
+		return fmt.Errorf("failed to return terminal (%s) to inventory: %w", record.Serial, err)
+	}
+	return nil
+}
+
+// writeReturns writes CSV, which `reassign --csv` consumes to return terminals to the merchant inventory.
+func writeReturns(path string, returns []*Return) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return gocsv.MarshalFile(&returns, file)
+}
+
+// isStale checks the terminal was not active and had no transactions since the time.
+// Terminals, which were never active, are stale.
+func isStale(terminal *adyen.Terminal, since time.Time) bool {
+	return lastSeen(terminal).Before(since)
+}
+
+// lastSeen returns the time of the last activity or transaction of the terminal, whatever is later.
+func lastSeen(terminal *adyen.Terminal) time.Time {
+	if terminal.LastTransactionAt.After(terminal.LastActivityAt) {
+		return terminal.LastTransactionAt
+	}
+	return terminal.LastActivityAt
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
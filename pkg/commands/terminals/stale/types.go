@@ -0,0 +1,29 @@
+package stale
+
+// Record declare one stale terminal in the report.
+type Record struct {
+	MerchantID        string `csv:"MERCHANT ID" json:"merchantId"`
+	StoreID           string `csv:"STORE ID" json:"storeReference"`
+	StoreDescription  string `csv:"STORE DESCRIPTION" json:"storeDescription"`
+	TerminalID        string `csv:"TERMINAL ID" json:"terminalId"`
+	Serial            string `csv:"SERIAL" json:"serial"`
+	Model             string `csv:"MODEL" json:"model"`
+	Status            string `csv:"STATUS" json:"status"`
+	LastActivityAt    string `csv:"LAST ACTIVITY AT" json:"lastActivityAt"`
+	LastTransactionAt string `csv:"LAST TRANSACTION AT" json:"lastTransactionAt"`
+	InactiveDays      string `csv:"INACTIVE DAYS" json:"inactiveDays"`
+}
+
+// Return declare one terminal to return to the merchant inventory.
+// Column names match the input of `reassign`.
+type Return struct {
+	Serial     string `csv:"SERIAL"`
+	TerminalID string `csv:"TERMINAL ID"`
+	MerchantID string `csv:"MERCHANT ID"`
+}
+
+// Allowed declare one terminal, which is never reported as stale.
+type Allowed struct {
+	Serial     string `csv:"SERIAL"`
+	TerminalID string `csv:"TERMINAL ID"`
+}
@@ -0,0 +1,21 @@
+package terminals
+
+// Record declare one exported terminal record.
+// 'Serial' and 'Terminal ID' match the input of `cellular` and `offline`, so the export can be used as their input.
+type Record struct {
+	TerminalID        string `csv:"TERMINAL ID" json:"terminalId"`
+	Serial            string `csv:"SERIAL" json:"serial"`
+	Model             string `csv:"MODEL" json:"model"`
+	FirmwareVersion   string `csv:"FIRMWARE VERSION" json:"firmwareVersion"`
+	Status            string `csv:"STATUS" json:"status"`
+	CompanyID         string `csv:"COMPANY ID" json:"companyId"`
+	MerchantID        string `csv:"MERCHANT ID" json:"merchantId"`
+	StoreID           string `csv:"STORE ID" json:"storeReference"`
+	StoreDescription  string `csv:"STORE DESCRIPTION" json:"storeDescription"`
+	LastActivityAt    string `csv:"LAST ACTIVITY AT" json:"lastActivityAt"`
+	LastTransactionAt string `csv:"LAST TRANSACTION AT" json:"lastTransactionAt"`
+	CellularStatus    string `csv:"CELLULAR STATUS" json:"cellularStatus"`
+	ICCID             string `csv:"ICCID" json:"iccid"`
+	WifiIPAddress     string `csv:"WIFI IP ADDRESS" json:"wifiIpAddress"`
+	WifiMACAddress    string `csv:"WIFI MAC ADDRESS" json:"wifiMacAddress"`
+}
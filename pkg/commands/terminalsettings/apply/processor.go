@@ -0,0 +1,204 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/settings"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger       *zap.Logger
+	client       *http.Client
+	adyenAPI     *adyen.API
+	resolver     *resolver.Resolver
+	runner       *commands.Runner
+	templatePath string
+	level        string
+	csvFilePath  string
+	filter       fleet.Filter
+	companyIDs   []string
+	dryRun       bool
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	templatePath, level, csvFilePath string, filter fleet.Filter, companyIDs []string, production, dryRun bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:       logger,
+		client:       client,
+		adyenAPI:     adyenAPI,
+		resolver:     resolver.New(logger, adyenAPI, config, production),
+		runner:       commands.NewRunner(logger, config),
+		templatePath: templatePath,
+		level:        level,
+		csvFilePath:  csvFilePath,
+		filter:       filter,
+		companyIDs:   companyIDs,
+		dryRun:       dryRun,
+	}
+}
+
+// Run runs applying of the settings template to companies, merchants, stores or terminals.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if err := fleet.ValidateLevel(p.level); err != nil {
+		return nil, err
+	}
+	if p.templatePath == "" && p.csvFilePath == "" {
+		return nil, fmt.Errorf("%w: template or CSV with overrides should be defined", commands.ErrInvalidInput)
+	}
+
+	var template map[string]interface{}
+	if p.templatePath != "" {
+		var err error
+		if template, err = settings.LoadTemplate(p.templatePath); err != nil {
+			return nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
+		}
+	}
+
+	input, err := p.targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.resolver.Save()
+
+	summary, err := commands.Process(ctx, p.runner, p.level+" settings", input,
+		func(ctx context.Context, target *Target) error {
+			return p.process(ctx, template, target)
+		})
+	if err != nil {
+		return summary, fmt.Errorf("failed to apply terminal settings: %w", err)
+	}
+	return summary, nil
+}
+
+// targets reads targets with overrides from CSV, if defined, or selects them by the flags otherwise.
+func (p *Processor) targets(ctx context.Context) (*commands.Input[Target], error) {
+	if p.csvFilePath != "" {
+		input, err := commands.NewInput[Target](p.csvFilePath)
+		if err != nil {
+			return nil, err
+		}
+		if p.level == adyen.LevelTerminal {
+			p.resolver.PrefetchTerminals(ctx, input.Total())
+		}
+		if p.level == adyen.LevelStore {
+			p.resolver.PrefetchStores(ctx, input.Total())
+		}
+		return input, nil
+	}
+
+	selected, err := fleet.Select(ctx, p.adyenAPI, p.resolver, p.level, "", &p.filter, p.companyIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select %s: %w", p.level, err)
+	}
+	targets := make([]*Target, 0, len(selected))
+	for i := range selected {
+		targets = append(targets, &Target{resolved: &selected[i]})
+	}
+	return commands.NewRecordsInput(targets), nil
+}
+
+func (p *Processor) process(ctx context.Context, template map[string]interface{}, target *Target) error {
+	resolved := target.resolved
+	if resolved == nil {
+		t, err := fleet.Resolve(ctx, p.resolver, p.level, &target.Record)
+		if err != nil {
+			return err
+		}
+		resolved = &t
+	}
+
+	current, err := p.adyenAPI.TerminalSettingsDocument(ctx, resolved.SettingsTarget)
+	if err != nil {
+		return fmt.Errorf("failed to get terminal settings: %w", err)
+	}
+	desired, err := desiredSettings(template, target.Overrides, current)
+	if err != nil {
+		return fmt.Errorf("failed to parse overrides: %w", err)
+	}
+
+	changes := settings.Diff(current, desired)
+	for _, change := range changes {
+		logChange(p.logger, resolved, &change)
+	}
+	if len(changes) == 0 || p.dryRun {
+		return nil
+	}
+
+	if _, err := p.adyenAPI.PatchTerminalSettings(ctx, resolved.SettingsTarget, settings.Patch(changes)); err != nil {
+		return fmt.Errorf("failed to patch terminal settings: %w", err)
+	}
+	return nil
+}
+
+// desiredSettings applies the overrides on top of the template.
+// The overrides are parsed using the type of the current value.
+func desiredSettings(
+	template map[string]interface{}, overrides map[string]string, current map[string]interface{},
+) (map[string]interface{}, error) {
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	parsed := make(map[string]interface{})
+	for _, path := range paths {
+		known, ok := settings.Get(current, path)
+		if !ok {
+			known, _ = settings.Get(template, path)
+		}
+		value, err := settings.ParseValue(overrides[path], known)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		settings.Set(parsed, path, value)
+	}
+	return settings.Merge(template, parsed), nil
+}
+
+func logChange(logger *zap.Logger, target *fleet.Target, change *settings.Change) {
+	current, desired := change.Format()
+	logger.
+		With(zap.String("Level", target.Level)).
+		With(zap.String("ID", target.ID)).
+		With(zap.String("Name", target.Name)).
+		With(zap.String("Path", change.Path)).
+		With(zap.String("Current", current)).
+		With(zap.String("Desired", desired)).
+		Info("Terminal setting differs")
+}
@@ -0,0 +1,54 @@
+package apply
+
+import (
+	"strings"
+
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+)
+
+const (
+	columnCompanyID  = "COMPANY ID"
+	columnMerchantID = "MERCHANT ID"
+	columnStoreID    = "STORE ID"
+	columnTerminalID = "TERMINAL ID"
+	columnSerial     = "SERIAL"
+)
+
+// Target declare one company, merchant, store or terminal to apply the template to,
+// with its own overrides of the template.
+type Target struct {
+	Record fleet.Record
+	// Overrides maps the dotted path of the setting to the raw value from CSV.
+	Overrides map[string]string
+
+	// resolved is defined, if the target is selected by the filter and already resolved.
+	resolved *fleet.Target
+}
+
+// UnmarshalRow decodes the target and its overrides from CSV.
+// 'Company ID', 'Merchant ID', 'Store ID', 'Terminal ID' and 'Serial' columns define the target,
+// any other column is the dotted path of the setting.
+// Empty cells don't override the template.
+func (t *Target) UnmarshalRow(header, row []string) error {
+	t.Overrides = make(map[string]string)
+	for i, column := range header {
+		value := strings.TrimSpace(row[i])
+		switch strings.ToUpper(strings.TrimSpace(column)) {
+		case columnCompanyID:
+			t.Record.CompanyID = value
+		case columnMerchantID:
+			t.Record.MerchantID = value
+		case columnStoreID:
+			t.Record.StoreID = value
+		case columnTerminalID:
+			t.Record.TerminalID = value
+		case columnSerial:
+			t.Record.Serial = value
+		default:
+			if value != "" {
+				t.Overrides[strings.TrimSpace(column)] = value
+			}
+		}
+	}
+	return nil
+}
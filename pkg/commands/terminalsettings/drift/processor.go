@@ -0,0 +1,257 @@
+package drift
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/settings"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger              *zap.Logger
+	client              *http.Client
+	adyenAPI            *adyen.API
+	resolver            *resolver.Resolver
+	runner              *commands.Runner
+	baselinePath        string
+	level               string
+	csvFilePath         string
+	filter              fleet.Filter
+	companyIDs          []string
+	reportFilePath      string
+	remediationFilePath string
+
+	mu           sync.Mutex
+	drifts       []Drift
+	remediations []remediation
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	baselinePath, level, csvFilePath string, filter fleet.Filter, companyIDs []string,
+	reportFilePath, remediationFilePath string, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:              logger,
+		client:              client,
+		adyenAPI:            adyenAPI,
+		resolver:            resolver.New(logger, adyenAPI, config, production),
+		runner:              commands.NewRunner(logger, config),
+		baselinePath:        baselinePath,
+		level:               level,
+		csvFilePath:         csvFilePath,
+		filter:              filter,
+		companyIDs:          companyIDs,
+		reportFilePath:      reportFilePath,
+		remediationFilePath: remediationFilePath,
+	}
+}
+
+// Run runs detection of terminal settings drift against the baseline.
+// Returns commands.ErrDrift, if any settings drifted and there are no other failures.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	baseline, err := settings.LoadTemplate(p.baselinePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
+	}
+
+	targets, err := fleet.Select(ctx, p.adyenAPI, p.resolver, p.level, p.csvFilePath, &p.filter, p.companyIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select %s: %w", p.level, err)
+	}
+	defer p.resolver.Save()
+
+	records := make([]*fleet.Target, 0, len(targets))
+	for i := range targets {
+		records = append(records, &targets[i])
+	}
+	summary, err := commands.Process(ctx, p.runner, p.level+" settings", commands.NewRecordsInput(records),
+		func(ctx context.Context, target *fleet.Target) error {
+			return p.process(ctx, baseline, target)
+		})
+	if writeErr := p.write(); writeErr != nil {
+		return summary, fmt.Errorf("failed to detect terminal settings drift: %w", writeErr)
+	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to detect terminal settings drift: %w", err)
+	}
+
+	if len(p.remediations) > 0 {
+		p.logger.
+			With(zap.Int("Drifted Count", len(p.remediations))).
+			With(zap.Int("Drift Count", len(p.drifts))).
+			Warn("Terminal settings drifted")
+		return summary, fmt.Errorf("%w: %d %s settings drifted", commands.ErrDrift, len(p.remediations), p.level)
+	}
+	return summary, nil
+}
+
+func (p *Processor) process(ctx context.Context, baseline map[string]interface{}, target *fleet.Target) error {
+	current, err := p.adyenAPI.TerminalSettingsDocument(ctx, target.SettingsTarget)
+	if err != nil {
+		return fmt.Errorf("failed to get terminal settings: %w", err)
+	}
+
+	changes := settings.Diff(current, baseline)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	fix := remediation{
+		id:     target.ID,
+		name:   target.Name,
+		values: make(map[string]string, len(changes)),
+	}
+	drifts := make([]Drift, 0, len(changes))
+	for i := range changes {
+		currentValue, baselineValue := changes[i].Format()
+		p.logger.
+			With(zap.String("Level", target.Level)).
+			With(zap.String("ID", target.ID)).
+			With(zap.String("Name", target.Name)).
+			With(zap.String("Path", changes[i].Path)).
+			With(zap.String("Current", currentValue)).
+			With(zap.String("Baseline", baselineValue)).
+			Warn("Terminal setting drifted")
+
+		drifts = append(drifts, Drift{
+			Level:    target.Level,
+			ID:       target.ID,
+			Name:     target.Name,
+			Path:     changes[i].Path,
+			Current:  currentValue,
+			Baseline: baselineValue,
+		})
+		fix.values[changes[i].Path] = settings.FormatValue(changes[i].Desired)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.drifts = append(p.drifts, drifts...)
+	p.remediations = append(p.remediations, fix)
+	return nil
+}
+
+// write writes the drift report and the remediation file, if requested.
+func (p *Processor) write() error {
+	if p.reportFilePath != "" {
+		file, err := os.Create(p.reportFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer file.Close()
+		if err := gocsv.MarshalFile(&p.drifts, file); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	if p.remediationFilePath != "" {
+		if err := writeRemediation(p.remediationFilePath, p.level, p.remediations); err != nil {
+			return fmt.Errorf("failed to write remediation: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeRemediation writes CSV, which `terminal-settings apply --csv` consumes:
+// the identity columns of the level and one column per drifted setting with the baseline value.
+func writeRemediation(path, level string, remediations []remediation) error {
+	columns := make(map[string]struct{})
+	for _, fix := range remediations {
+		for column := range fix.values {
+			columns[column] = struct{}{}
+		}
+	}
+	paths := make([]string, 0, len(columns))
+	for column := range columns {
+		paths = append(paths, column)
+	}
+	sort.Strings(paths)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	identity := identityColumns(level)
+	if err := writer.Write(append(identity, paths...)); err != nil {
+		return err
+	}
+	for _, fix := range remediations {
+		row := make([]string, 0, len(paths)+len(identity))
+		row = append(row, identityValues(level, &fix)...)
+		for _, column := range paths {
+			row = append(row, fix.values[column])
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func identityColumns(level string) []string {
+	switch level {
+	case adyen.LevelCompany:
+		return []string{"COMPANY ID"}
+	case adyen.LevelMerchant:
+		return []string{"MERCHANT ID"}
+	case adyen.LevelStore:
+		return []string{"STORE ID"}
+	default:
+		return []string{"TERMINAL ID", "SERIAL"}
+	}
+}
+
+// identityValues returns values of the identity columns, stores are identified by the reference.
+func identityValues(level string, fix *remediation) []string {
+	switch level {
+	case adyen.LevelCompany, adyen.LevelMerchant:
+		return []string{fix.id}
+	case adyen.LevelStore:
+		return []string{fix.name}
+	default:
+		return []string{fix.id, fix.name}
+	}
+}
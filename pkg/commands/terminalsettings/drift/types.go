@@ -0,0 +1,18 @@
+package drift
+
+// Drift declare one setting of one company, merchant, store or terminal, which differs from the baseline.
+type Drift struct {
+	Level    string `csv:"LEVEL"`
+	ID       string `csv:"ID"`
+	Name     string `csv:"NAME"`
+	Path     string `csv:"PATH"`
+	Current  string `csv:"CURRENT"`
+	Baseline string `csv:"BASELINE"`
+}
+
+// remediation declare the baseline values of the drifted settings of one company, merchant, store or terminal.
+type remediation struct {
+	id     string
+	name   string
+	values map[string]string
+}
@@ -0,0 +1,201 @@
+package explain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/settings"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	runner      *commands.Runner
+	out         io.Writer
+	terminal    string
+	outFilePath string
+	showSecrets bool
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config, out io.Writer,
+	terminal, outFilePath string, showSecrets, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey),
+		runner:      commands.NewRunner(logger, config),
+		out:         out,
+		terminal:    terminal,
+		outFilePath: outFilePath,
+		showSecrets: showSecrets,
+	}
+}
+
+// Run runs explanation of the effective terminal settings.
+// Settings of the company, merchant, store and the terminal itself are fetched to find the level,
+// which defined every effective value.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if p.terminal == "" {
+		return nil, fmt.Errorf("%w: no terminal ID or serial number defined", commands.ErrInvalidInput)
+	}
+
+	summary, err := commands.Process(ctx, p.runner, "terminals", commands.NewRecordsInput([]*string{&p.terminal}), p.process)
+	if err != nil {
+		return summary, fmt.Errorf("failed to explain terminal settings: %w", err)
+	}
+	return summary, nil
+}
+
+func (p *Processor) process(ctx context.Context, query *string) error {
+	terminal, err := p.findTerminal(ctx, *query)
+	if err != nil {
+		return err
+	}
+
+	layers, err := p.layers(ctx, terminal)
+	if err != nil {
+		return err
+	}
+
+	origins := settings.Explain(layers)
+	rows := make([]Row, 0, len(origins))
+	overrides := 0
+	for i := range origins {
+		value, overridden := origins[i].Format()
+		if p.showSecrets {
+			value, overridden = settings.FormatValue(origins[i].Value), settings.FormatValue(origins[i].OverriddenValue)
+		}
+		if origins[i].Level == adyen.LevelTerminal && origins[i].OverriddenLevel != "" {
+			overrides++
+			p.logger.
+				With(zap.String("TerminalID", terminal.ID)).
+				With(zap.String("Path", origins[i].Path)).
+				With(zap.String("Value", value)).
+				With(zap.String("Overridden Level", origins[i].OverriddenLevel)).
+				With(zap.String("Overridden Value", overridden)).
+				Warn("Terminal setting overrides the standard")
+		}
+		rows = append(rows, Row{
+			Path:            origins[i].Path,
+			Value:           value,
+			Level:           origins[i].Level,
+			OverriddenLevel: origins[i].OverriddenLevel,
+			OverriddenValue: overridden,
+		})
+	}
+
+	if err := p.write(rows); err != nil {
+		return fmt.Errorf("failed to write terminal settings: %w", err)
+	}
+
+	p.logger.
+		With(zap.String("TerminalID", terminal.ID)).
+		With(zap.String("Serial", terminal.SerialNumber)).
+		With(zap.String("StoreID", terminal.Assignment.StoreID)).
+		With(zap.String("MerchantID", terminal.Assignment.MerchantID)).
+		With(zap.String("CompanyID", terminal.Assignment.CompanyID)).
+		With(zap.Int("Count", len(rows))).
+		With(zap.Int("Terminal Overrides Count", overrides)).
+		Info("Explained terminal settings")
+	return nil
+}
+
+// findTerminal finds the terminal by its ID or serial number.
+func (p *Processor) findTerminal(ctx context.Context, query string) (*adyen.Terminal, error) {
+	terminals, err := p.adyenAPI.SearchTerminals(ctx, "", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find terminal: %w", err)
+	}
+	for i := range terminals.Data {
+		if terminals.Data[i].ID == query || terminals.Data[i].SerialNumber == query {
+			return &terminals.Data[i], nil
+		}
+	}
+	return nil, fmt.Errorf("terminal not found: %s", query)
+}
+
+// layers fetches the settings of every level, the terminal is assigned to, from the company down to the terminal.
+func (p *Processor) layers(ctx context.Context, terminal *adyen.Terminal) ([]settings.Layer, error) {
+	targets := []adyen.SettingsTarget{
+		{Level: adyen.LevelCompany, ID: terminal.Assignment.CompanyID},
+		{Level: adyen.LevelMerchant, ID: terminal.Assignment.MerchantID},
+		{Level: adyen.LevelStore, ID: terminal.Assignment.StoreID},
+		{Level: adyen.LevelTerminal, ID: terminal.ID},
+	}
+
+	layers := make([]settings.Layer, 0, len(targets))
+	for _, target := range targets {
+		if target.ID == "" {
+			continue
+		}
+		document, err := p.adyenAPI.TerminalSettingsDocument(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s settings: %w", target.Level, err)
+		}
+		layers = append(layers, settings.Layer{Level: target.Level, Settings: document})
+	}
+	return layers, nil
+}
+
+// write writes the settings to CSV file, if defined, or prints the table otherwise.
+// Terminal level overrides of the standard are marked with "!".
+func (p *Processor) write(rows []Row) error {
+	if p.outFilePath != "" {
+		file, err := os.Create(p.outFilePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return gocsv.MarshalFile(&rows, file)
+	}
+
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "\tPATH\tVALUE\tLEVEL\tOVERRIDDEN LEVEL\tOVERRIDDEN VALUE")
+	for _, row := range rows {
+		mark := ""
+		if row.Level == adyen.LevelTerminal && row.OverriddenLevel != "" {
+			mark = "!"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			mark, row.Path, row.Value, row.Level, row.OverriddenLevel, row.OverriddenValue)
+	}
+	return writer.Flush()
+}
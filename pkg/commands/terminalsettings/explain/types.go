@@ -0,0 +1,10 @@
+package explain
+
+// Row declare one effective setting of the terminal and the level, which defined it.
+type Row struct {
+	Path            string `csv:"PATH"`
+	Value           string `csv:"VALUE"`
+	Level           string `csv:"LEVEL"`
+	OverriddenLevel string `csv:"OVERRIDDEN LEVEL"`
+	OverriddenValue string `csv:"OVERRIDDEN VALUE"`
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/settings"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	runner      *commands.Runner
+	level       string
+	csvFilePath string
+	filter      fleet.Filter
+	companyIDs  []string
+	outFilePath string
+	format      string
+	showSecrets bool
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	level, csvFilePath string, filter fleet.Filter, companyIDs []string, outFilePath, format string,
+	showSecrets, production bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		runner:      commands.NewRunner(logger, config),
+		level:       level,
+		csvFilePath: csvFilePath,
+		filter:      filter,
+		companyIDs:  companyIDs,
+		outFilePath: outFilePath,
+		format:      format,
+		showSecrets: showSecrets,
+	}
+}
+
+// Run runs export of the terminal settings of companies, merchants, stores or terminals.
+// JSON contains one document per entity, CSV contains one row per setting with the dotted path.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	if err := fleet.ValidateLevel(p.level); err != nil {
+		return nil, err
+	}
+	defer p.resolver.Save()
+
+	var summary *commands.Summary
+	var err error
+	if p.format == commands.FormatCSV {
+		summary, err = commands.Export(ctx, p.runner, "terminal settings", p.outFilePath, p.format, p.fetchRows)
+	} else {
+		summary, err = commands.Export(ctx, p.runner, "terminal settings", p.outFilePath, p.format, p.fetchDocuments)
+	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to export terminal settings: %w", err)
+	}
+	return summary, nil
+}
+
+func (p *Processor) fetchDocuments(ctx context.Context) ([]Document, error) {
+	targets, err := fleet.Select(ctx, p.adyenAPI, p.resolver, p.level, p.csvFilePath, &p.filter, p.companyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]Document, 0, len(targets))
+	for _, target := range targets {
+		document, err := p.adyenAPI.TerminalSettingsDocument(ctx, target.SettingsTarget)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get settings of %s: %w", target, err)
+		}
+		if !p.showSecrets {
+			document = settings.Mask(document)
+		}
+		documents = append(documents, Document{
+			Level:    target.Level,
+			ID:       target.ID,
+			Name:     target.Name,
+			Settings: document,
+		})
+	}
+	return documents, nil
+}
+
+func (p *Processor) fetchRows(ctx context.Context) ([]Row, error) {
+	documents, err := p.fetchDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for i := range documents {
+		for _, field := range settings.Flatten(documents[i].Settings) {
+			rows = append(rows, Row{
+				Level: documents[i].Level,
+				ID:    documents[i].ID,
+				Name:  documents[i].Name,
+				Path:  field.Path,
+				Value: settings.FormatValue(field.Value),
+			})
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,18 @@
+package export
+
+// Document declare the settings of one company, merchant, store or terminal, exported as JSON.
+type Document struct {
+	Level    string                 `json:"level"`
+	ID       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// Row declare one setting of one company, merchant, store or terminal, exported as CSV.
+type Row struct {
+	Level string `csv:"LEVEL"`
+	ID    string `csv:"ID"`
+	Name  string `csv:"NAME"`
+	Path  string `csv:"PATH"`
+	Value string `csv:"VALUE"`
+}
@@ -1,5 +1,7 @@
 package commands
 
+import "time"
+
 // Config declare processor's configuration.
 type Config struct {
 	AdyenCalKey      string `env:"ADYEN_CAL_KEY,required"`
@@ -18,4 +20,15 @@ type Config struct {
 	AdyenKycTestURL  string `env:"ADYEN_KYC_TEST_URL,required"`
 	AdyenBalURL      string `env:"ADYEN_BAL_URL,required"`
 	AdyenBalTestURL  string `env:"ADYEN_BAL_TEST_URL,required"`
+
+	// GracePeriod defines how long in-flight requests can run after the interruption.
+	GracePeriod time.Duration
+	// CacheFile defines the file to persist resolved identifiers, no persistence if empty.
+	CacheFile string
+	// CacheTTL defines how long resolved identifiers are valid in the cache file.
+	CacheTTL time.Duration
+	// RecordOutcomes defines if per-row outcomes are kept for the run summary.
+	RecordOutcomes bool
+	// Console defines the interactive terminal to show the progress, nil if the run is not interactive.
+	Console *Console
 }
@@ -0,0 +1,146 @@
+package uninstall
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/fleet"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
+)
+
+// Processor declare implementation of the main module.
+type Processor struct {
+	logger      *zap.Logger
+	client      *http.Client
+	adyenAPI    *adyen.API
+	resolver    *resolver.Resolver
+	apps        *schedule.Apps
+	runner      *commands.Runner
+	csvFilePath string
+	selector    string
+	selected    Record
+	tracking    schedule.Tracking
+	dryRun      bool
+
+	mu      sync.Mutex
+	actions []*schedule.Action
+}
+
+// New creates new instance of Processor.
+func New(
+	logger *zap.Logger, client *http.Client, config *commands.Config,
+	csvFilePath, selector string, selected Record, tracking schedule.Tracking, production, dryRun bool) *Processor {
+	var calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey string
+	switch {
+	case production:
+		calURL = config.AdyenCalURL
+		calKey = config.AdyenCalKey
+		mgmtURL = config.AdyenMgmtURL
+		mgmtKey = config.AdyenMgmtKey
+		kycURL = config.AdyenKycURL
+		kycKey = config.AdyenKycKey
+		balURL = config.AdyenBalURL
+		balKey = config.AdyenBalKey
+	case !production:
+		calURL = config.AdyenCalTestURL
+		calKey = config.AdyenCalTestKey
+		mgmtURL = config.AdyenMgmtTestURL
+		mgmtKey = config.AdyenMgmtTestKey
+		kycURL = config.AdyenKycTestURL
+		kycKey = config.AdyenKycTestKey
+		balURL = config.AdyenBalTestURL
+		balKey = config.AdyenBalTestKey
+	}
+
+	gocsv.SetHeaderNormalizer(strings.ToUpper)
+
+	adyenAPI := adyen.New(logger, client, calURL, calKey, mgmtURL, mgmtKey, kycURL, kycKey, balURL, balKey)
+	return &Processor{
+		logger:      logger,
+		client:      client,
+		adyenAPI:    adyenAPI,
+		resolver:    resolver.New(logger, adyenAPI, config, production),
+		apps:        schedule.NewApps(adyenAPI),
+		runner:      commands.NewRunner(logger, config),
+		csvFilePath: csvFilePath,
+		selector:    selector,
+		selected:    selected,
+		tracking:    tracking,
+		dryRun:      dryRun,
+	}
+}
+
+// Run runs parsing & app uninstallation.
+func (p *Processor) Run(ctx context.Context) (*commands.Summary, error) {
+	input, err := p.input(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.resolver.PrefetchStores(ctx, input.Total())
+	defer p.resolver.Save()
+
+	summary, err := commands.Process(ctx, p.runner, "uninstallations", input, p.process)
+	if trackErr := schedule.Track(ctx, p.logger, p.adyenAPI, p.actions, &p.tracking); trackErr != nil && err == nil {
+		err = trackErr
+	}
+	if err != nil {
+		return summary, fmt.Errorf("failed to process uninstallations: %w", err)
+	}
+	return summary, nil
+}
+
+// input returns records from CSV, or records for terminals matching the selector.
+// The app and the date of selected terminals are the same for all of them.
+func (p *Processor) input(ctx context.Context) (*commands.Input[Record], error) {
+	if p.selector != "" && p.csvFilePath == "" && p.selected.PackageName == "" {
+		return nil, fmt.Errorf("%w: no package name defined for selected terminals", commands.ErrInvalidInput)
+	}
+	return fleet.NewInput(ctx, p.adyenAPI, p.resolver, p.csvFilePath, p.selector,
+		func(terminal *adyen.Terminal) *Record {
+			record := p.selected
+			record.CompanyID = terminal.Assignment.CompanyID
+			record.TerminalID = terminal.ID
+			return &record
+		})
+}
+
+func (p *Processor) process(ctx context.Context, record *Record) error {
+	terminalIDs, err := schedule.Terminals(ctx, p.adyenAPI, p.resolver, record.StoreID, record.TerminalFilter, record.TerminalID)
+	if err != nil {
+		return fmt.Errorf("failed to get terminals: %w", err)
+	}
+	appID, err := p.apps.ID(ctx, record.CompanyID, record.PackageName, record.VersionName)
+	if err != nil {
+		return fmt.Errorf("failed to get app id: %w", err)
+	}
+
+	at, err := schedule.At(record.Date, nil)
+	if err != nil {
+		return err
+	}
+
+	if p.dryRun {
+		return nil
+	}
+
+	details := adyen.ActionDetails{Type: adyen.ActionUninstallAndroidApp, AppID: appID}
+	actions, err := schedule.Schedule(
+		ctx, p.logger, p.adyenAPI, record.CompanyID, details, "", terminalIDs, at)
+	p.mu.Lock()
+	p.actions = append(p.actions, actions...)
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to process app uninstallations: %w", err)
+	}
+	return nil
+}
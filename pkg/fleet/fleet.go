@@ -0,0 +1,207 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+const pageSize = 100
+
+// Filter declare the terminals to select, empty lists mean no filter.
+type Filter struct {
+	MerchantIDs []string
+	StoreIDs    []string
+	Models      []string
+	Statuses    []string
+}
+
+// Search pages through all terminals, which match the filter.
+// Store references are resolved to IDs, statuses are filtered locally, Adyen can't do it.
+func Search(
+	ctx context.Context, adyenAPI *adyen.API, resolver *resolver.Resolver, filter *Filter,
+) ([]adyen.Terminal, error) {
+	apiFilter := adyen.TerminalsFilter{
+		MerchantIDs: filter.MerchantIDs,
+		BrandModels: filter.Models,
+	}
+	for _, reference := range filter.StoreIDs {
+		store, err := resolver.Store(ctx, reference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve store: %w", err)
+		}
+		apiFilter.StoreIDs = append(apiFilter.StoreIDs, store.ID)
+	}
+
+	var terminals []adyen.Terminal
+	for pageNumber, pagesTotal := 1, 1; pageNumber <= pagesTotal; pageNumber++ {
+		page, err := adyenAPI.Terminals(ctx, &apiFilter, pageNumber, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.Data {
+			if filter.matchStatus(&page.Data[i]) {
+				terminals = append(terminals, page.Data[i])
+			}
+		}
+		pagesTotal = page.PagesTotal
+	}
+	return terminals, nil
+}
+
+func (f *Filter) matchStatus(terminal *adyen.Terminal) bool {
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, status := range f.Statuses {
+		if strings.EqualFold(status, terminal.Assignment.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record declare one entity in the list: company, merchant, store or terminal, depending on the level.
+type Record struct {
+	CompanyID  string `csv:"COMPANY ID"`
+	MerchantID string `csv:"MERCHANT ID"`
+	StoreID    string `csv:"STORE ID"`
+	Serial     string `csv:"SERIAL"`
+	TerminalID string `csv:"TERMINAL ID"`
+}
+
+// Target declare one selected owner of terminal settings.
+type Target struct {
+	adyen.SettingsTarget
+	// Name declare human-readable identifier: the reference of the store, the serial number of the terminal.
+	Name string
+}
+
+// ValidateLevel checks the level of terminal settings.
+func ValidateLevel(level string) error {
+	switch level {
+	case adyen.LevelCompany, adyen.LevelMerchant, adyen.LevelStore, adyen.LevelTerminal:
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported level: %s", commands.ErrInvalidInput, level)
+	}
+}
+
+// Resolve resolves the record to the owner of terminal settings on the level.
+// Store references and terminal serial numbers are resolved to IDs.
+func Resolve(ctx context.Context, resolver *resolver.Resolver, level string, record *Record) (Target, error) {
+	switch level {
+	case adyen.LevelCompany:
+		if record.CompanyID == "" {
+			return Target{}, fmt.Errorf("no company id defined")
+		}
+		return newTarget(level, record.CompanyID, record.CompanyID), nil
+	case adyen.LevelMerchant:
+		if record.MerchantID == "" {
+			return Target{}, fmt.Errorf("no merchant id defined")
+		}
+		return newTarget(level, record.MerchantID, record.MerchantID), nil
+	case adyen.LevelStore:
+		if record.StoreID == "" {
+			return Target{}, fmt.Errorf("no store id defined")
+		}
+		store, err := resolver.Store(ctx, record.StoreID)
+		if err != nil {
+			return Target{}, fmt.Errorf("failed to resolve store: %w", err)
+		}
+		return newTarget(level, store.ID, store.Reference), nil
+	default:
+		terminalID := record.TerminalID
+		if terminalID == "" && record.Serial != "" {
+			id, err := resolver.TerminalID(ctx, record.Serial)
+			if err != nil {
+				return Target{}, fmt.Errorf("failed to resolve terminal: %w", err)
+			}
+			terminalID = id
+		}
+		if terminalID == "" {
+			return Target{}, fmt.Errorf("no terminal id and serial number defined")
+		}
+		return newTarget(adyen.LevelTerminal, terminalID, record.Serial), nil
+	}
+}
+
+// Select selects owners of terminal settings on the level from the CSV list, if defined, or by the flags otherwise.
+// Terminals are selected by the filter, merchants and stores by the filter's merchants and stores,
+// companies by companyIDs.
+func Select(
+	ctx context.Context, adyenAPI *adyen.API, resolver *resolver.Resolver,
+	level, csvFilePath string, filter *Filter, companyIDs []string,
+) ([]Target, error) {
+	if err := ValidateLevel(level); err != nil {
+		return nil, err
+	}
+
+	var records []*Record
+	switch {
+	case csvFilePath != "":
+		input, err := commands.NewInput[Record](csvFilePath)
+		if err != nil {
+			return nil, err
+		}
+		if records, err = input.Records(); err != nil {
+			return nil, err
+		}
+	case level == adyen.LevelTerminal:
+		return search(ctx, adyenAPI, resolver, filter)
+	case level == adyen.LevelCompany:
+		for _, id := range companyIDs {
+			records = append(records, &Record{CompanyID: id})
+		}
+	case level == adyen.LevelMerchant:
+		for _, id := range filter.MerchantIDs {
+			records = append(records, &Record{MerchantID: id})
+		}
+	case level == adyen.LevelStore:
+		for _, id := range filter.StoreIDs {
+			records = append(records, &Record{StoreID: id})
+		}
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no %s selected", commands.ErrInvalidInput, level)
+	}
+
+	if level == adyen.LevelTerminal {
+		resolver.PrefetchTerminals(ctx, len(records))
+	}
+	if level == adyen.LevelStore {
+		resolver.PrefetchStores(ctx, len(records))
+	}
+	targets := make([]Target, 0, len(records))
+	for _, record := range records {
+		target, err := Resolve(ctx, resolver, level, record)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+func search(ctx context.Context, adyenAPI *adyen.API, resolver *resolver.Resolver, filter *Filter) ([]Target, error) {
+	terminals, err := Search(ctx, adyenAPI, resolver, filter)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]Target, 0, len(terminals))
+	for i := range terminals {
+		targets = append(targets, newTarget(adyen.LevelTerminal, terminals[i].ID, terminals[i].SerialNumber))
+	}
+	return targets, nil
+}
+
+func newTarget(level, id, name string) Target {
+	return Target{
+		SettingsTarget: adyen.SettingsTarget{Level: level, ID: id},
+		Name:           name,
+	}
+}
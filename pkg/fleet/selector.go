@@ -0,0 +1,328 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+	"github.com/Toshik1978/csv2adyen/pkg/version"
+)
+
+// Selector fields.
+
+const (
+	fieldCompany         = "company"
+	fieldMerchant        = "merchant"
+	fieldStore           = "store"
+	fieldModel           = "model"
+	fieldStatus          = "status"
+	fieldSerial          = "serial"
+	fieldID              = "id"
+	fieldCellular        = "cellular"
+	fieldFirmware        = "firmware"
+	fieldLastActivity    = "lastactivity"
+	fieldLastTransaction = "lasttransaction"
+)
+
+// day declare the duration of the day, the selector accepts ages in days and weeks.
+const day = 24 * time.Hour
+
+// selectorOperators declare comparison operators of the selector, longer ones go first.
+var selectorOperators = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// ErrInvalidSelector means the selector can't be parsed.
+var ErrInvalidSelector = errors.New("invalid selector")
+
+// condition declare one condition of the selector, like `model=S1F2,V400M` or `firmware<1.90`.
+type condition struct {
+	field    string
+	operator string
+	values   []string
+	// at is the time to compare with, age is set if the time was defined as the age, like `30d`.
+	at  time.Time
+	age bool
+	// server is set if the condition is applied by Adyen.
+	server bool
+}
+
+// Selector declare the query to select terminals, like `store=ST123 model=S1F2 firmware<1.90 lastActivity>30d`.
+// All conditions must match, values of one condition are separated by commas and any of them must match.
+type Selector struct {
+	conditions []*condition
+}
+
+// ParseSelector parses the query. Conditions are separated by spaces:
+//   - company, merchant, store, model, status, serial, id and cellular (status) support `=` and `!=`;
+//   - firmware supports version comparisons: `=`, `!=`, `<`, `<=`, `>`, `>=`;
+//   - lastActivity and lastTransaction support time comparisons with dates, like `2024-01-02`,
+//     or ages, like `30d`: `lastActivity>30d` selects terminals, which were not active for more than 30 days.
+func ParseSelector(query string) (*Selector, error) {
+	var selector Selector
+	for _, field := range strings.Fields(query) {
+		c, err := parseCondition(field)
+		if err != nil {
+			return nil, err
+		}
+		selector.conditions = append(selector.conditions, c)
+	}
+	if len(selector.conditions) == 0 {
+		return nil, fmt.Errorf("%w: no conditions defined", ErrInvalidSelector)
+	}
+	return &selector, nil
+}
+
+func parseCondition(field string) (*condition, error) {
+	index := strings.IndexAny(field, "!=<>")
+	if index <= 0 {
+		return nil, fmt.Errorf("%w: %q has no field or operator", ErrInvalidSelector, field)
+	}
+	c := &condition{field: strings.ToLower(field[:index])}
+	for _, operator := range selectorOperators {
+		if strings.HasPrefix(field[index:], operator) {
+			c.operator = operator
+			break
+		}
+	}
+	value := field[index+len(c.operator):]
+	if c.operator == "" || value == "" {
+		return nil, fmt.Errorf("%w: %q has no operator or value", ErrInvalidSelector, field)
+	}
+
+	if err := c.parseValue(value); err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidSelector, field, err)
+	}
+	return c, nil
+}
+
+// parseValue parses the value of the condition depending on its field.
+func (c *condition) parseValue(value string) error {
+	switch c.field {
+	case fieldCompany, fieldMerchant, fieldStore, fieldModel, fieldStatus, fieldSerial, fieldID, fieldCellular:
+		if c.operator != "=" && c.operator != "!=" {
+			return fmt.Errorf("%s supports = and != only", c.field)
+		}
+		c.values = strings.Split(value, ",")
+	case fieldFirmware:
+		if !version.IsValid(value) {
+			return fmt.Errorf("expected the version, like 1.90")
+		}
+		c.values = []string{value}
+	case fieldLastActivity, fieldLastTransaction:
+		at, age, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		c.at, c.age = at, age
+	default:
+		return fmt.Errorf("unknown field %s", c.field)
+	}
+	return nil
+}
+
+// parseTime parses the date, or the age in days (30d), weeks (2w), hours (12h) or minutes (90m).
+func parseTime(value string) (at time.Time, age bool, err error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if at, err := time.Parse(layout, value); err == nil {
+			return at, false, nil
+		}
+	}
+
+	units := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': day, 'w': 7 * day}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("expected the date or the age, like 30d")
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, false, fmt.Errorf("expected the date or the age, like 30d")
+	}
+	return time.Now().Add(-time.Duration(n) * unit), true, nil
+}
+
+// Select pages through all terminals and returns ones, which match the selector.
+// Merchants, stores and models are filtered by Adyen, other conditions are checked locally.
+func (s *Selector) Select(ctx context.Context, adyenAPI *adyen.API, resolver *resolver.Resolver) ([]adyen.Terminal, error) {
+	filter := s.filter()
+	if err := s.resolveStores(ctx, resolver); err != nil {
+		return nil, err
+	}
+	terminals, err := Search(ctx, adyenAPI, resolver, &filter)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := terminals[:0]
+	for i := range terminals {
+		if s.match(&terminals[i]) {
+			selected = append(selected, terminals[i])
+		}
+	}
+	return selected, nil
+}
+
+// filter returns the filter, which Adyen applies, and marks its conditions.
+// Adyen matches any value of the field, so only the first condition of the field is applied by Adyen,
+// repeated ones are checked locally, all conditions must match.
+func (s *Selector) filter() Filter {
+	var filter Filter
+	applied := make(map[string]bool)
+	for _, c := range s.conditions {
+		if c.operator != "=" || applied[c.field] {
+			continue
+		}
+		switch c.field {
+		case fieldMerchant:
+			filter.MerchantIDs = append(filter.MerchantIDs, c.values...)
+		case fieldStore:
+			filter.StoreIDs = append(filter.StoreIDs, c.values...)
+		case fieldModel:
+			filter.Models = append(filter.Models, c.values...)
+		default:
+			continue
+		}
+		c.server = true
+		applied[c.field] = true
+	}
+	return filter
+}
+
+// resolveStores converts store references of local conditions to IDs, terminals refer to stores by IDs.
+func (s *Selector) resolveStores(ctx context.Context, resolver *resolver.Resolver) error {
+	for _, c := range s.conditions {
+		if c.field != fieldStore || c.server {
+			continue
+		}
+		for i, reference := range c.values {
+			store, err := resolver.Store(ctx, reference)
+			if err != nil {
+				return fmt.Errorf("failed to resolve store: %w", err)
+			}
+			c.values[i] = store.ID
+		}
+	}
+	return nil
+}
+
+func (s *Selector) match(terminal *adyen.Terminal) bool {
+	for _, c := range s.conditions {
+		if !c.server && !c.match(terminal) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *condition) match(terminal *adyen.Terminal) bool {
+	switch c.field {
+	case fieldFirmware:
+		return compare(version.Compare(FirmwareVersion(terminal.FirmwareVersion), c.values[0]), c.operator)
+	case fieldLastActivity:
+		return c.matchTime(terminal.LastActivityAt)
+	case fieldLastTransaction:
+		return c.matchTime(terminal.LastTransactionAt)
+	}
+
+	value := c.value(terminal)
+	for _, v := range c.values {
+		if strings.EqualFold(v, value) {
+			return c.operator == "="
+		}
+	}
+	return c.operator == "!="
+}
+
+// value returns the value of the terminal field, which is compared as the string.
+func (c *condition) value(terminal *adyen.Terminal) string {
+	switch c.field {
+	case fieldCompany:
+		return terminal.Assignment.CompanyID
+	case fieldMerchant:
+		return terminal.Assignment.MerchantID
+	case fieldStore:
+		return terminal.Assignment.StoreID
+	case fieldModel:
+		return terminal.Model
+	case fieldStatus:
+		return terminal.Assignment.Status
+	case fieldSerial:
+		return terminal.SerialNumber
+	case fieldID:
+		return terminal.ID
+	default:
+		return terminal.Connectivity.Cellular.Status
+	}
+}
+
+// matchTime compares the time, the age is compared in reverse: older terminals have bigger ages.
+func (c *condition) matchTime(at time.Time) bool {
+	result := at.Compare(c.at)
+	if c.age {
+		result = -result
+	}
+	return compare(result, c.operator)
+}
+
+// compare checks the result of the comparison against the operator.
+func compare(result int, operator string) bool {
+	switch operator {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	default:
+		return result >= 0
+	}
+}
+
+// FirmwareVersion returns the version of the firmware, like 1.90.7 of `Castles_Android 1.90.7`.
+func FirmwareVersion(firmware string) string {
+	fields := strings.Fields(firmware)
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i][0] >= '0' && fields[i][0] <= '9' {
+			return fields[i]
+		}
+	}
+	return firmware
+}
+
+// NewInput returns records from CSV file, or records of terminals, selected by the query, if it's defined.
+func NewInput[T any](
+	ctx context.Context, adyenAPI *adyen.API, resolver *resolver.Resolver,
+	csvFilePath, query string, record func(*adyen.Terminal) *T,
+) (*commands.Input[T], error) {
+	switch {
+	case csvFilePath != "" && query != "":
+		return nil, fmt.Errorf("%w: use either CSV file or the selector", commands.ErrInvalidInput)
+	case csvFilePath == "" && query == "":
+		return nil, fmt.Errorf("%w: no CSV file or selector defined", commands.ErrInvalidInput)
+	case csvFilePath != "":
+		return commands.NewInput[T](csvFilePath)
+	}
+
+	selector, err := ParseSelector(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", commands.ErrInvalidInput, err)
+	}
+	terminals, err := selector.Select(ctx, adyenAPI, resolver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select terminals: %w", err)
+	}
+
+	records := make([]*T, 0, len(terminals))
+	for i := range terminals {
+		records = append(records, record(&terminals[i]))
+	}
+	return commands.NewRecordsInput(records), nil
+}
@@ -0,0 +1,197 @@
+package fleet
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+)
+
+func TestParseSelectorErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "empty", query: " "},
+		{name: "no field", query: "=S1F2"},
+		{name: "no operator", query: "model"},
+		{name: "no value", query: "model="},
+		{name: "unknown field", query: "color=red"},
+		{name: "comparison of strings", query: "model<S1F2"},
+		{name: "firmware is not the version", query: "firmware<abc"},
+		{name: "firmware is the wildcard", query: "firmware=1.x"},
+		{name: "firmware has an empty part", query: "firmware>=1..90"},
+		{name: "firmware is the name", query: "firmware=Castles_Android"},
+		{name: "bad age", query: "lastActivity>30y"},
+		{name: "negative age", query: "lastActivity>-1d"},
+		{name: "bad date", query: "lastTransaction<2024-13-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSelector(tt.query); !errors.Is(err, ErrInvalidSelector) {
+				t.Errorf("ParseSelector(%q) error = %v, want %v", tt.query, err, ErrInvalidSelector)
+			}
+		})
+	}
+}
+
+func TestSelectorFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Filter
+	}{
+		{
+			name:  "server fields",
+			query: "merchant=M1 store=S1,S2 model=S1F2 status=boarded",
+			want:  Filter{MerchantIDs: []string{"M1"}, StoreIDs: []string{"S1", "S2"}, Models: []string{"S1F2"}},
+		},
+		{
+			name:  "negations are checked locally",
+			query: "store!=S1 model=V400M",
+			want:  Filter{Models: []string{"V400M"}},
+		},
+		{
+			name:  "only the first repeated condition is applied by Adyen",
+			query: "store=S1 store=S2 model=S1F2 model=V400M",
+			want:  Filter{StoreIDs: []string{"S1"}, Models: []string{"S1F2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSelector(tt.query)
+			if err != nil {
+				t.Fatalf("ParseSelector(%q) error = %v", tt.query, err)
+			}
+			if got := s.filter(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorMatch(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		query    string
+		terminal *adyen.Terminal
+		want     bool
+	}{
+		{
+			name:     "repeated store conditions must all match",
+			query:    "store=S1 store=S2",
+			terminal: newTerminal("S1", "1.90.7", now),
+			want:     false,
+		},
+		{
+			name:     "repeated store conditions with common store",
+			query:    "store=S1,S2 store=S2,S3",
+			terminal: newTerminal("S2", "1.90.7", now),
+			want:     true,
+		},
+		{
+			name:     "negation",
+			query:    "store!=S1",
+			terminal: newTerminal("S1", "1.90.7", now),
+			want:     false,
+		},
+		{
+			name:     "values are case insensitive",
+			query:    "status=BOARDED",
+			terminal: newTerminal("S1", "1.90.7", now),
+			want:     true,
+		},
+		{
+			name:     "older firmware",
+			query:    "firmware<1.90",
+			terminal: newTerminal("S1", "Castles_Android 1.89.9", now),
+			want:     true,
+		},
+		{
+			name:     "newer firmware",
+			query:    "firmware<1.90",
+			terminal: newTerminal("S1", "Castles_Android 1.90.7", now),
+			want:     false,
+		},
+		{
+			name:     "firmware range",
+			query:    "firmware>=1.80 firmware<1.90",
+			terminal: newTerminal("S1", "1.85", now),
+			want:     true,
+		},
+		{
+			name:     "inactive terminal",
+			query:    "lastActivity>30d",
+			terminal: newTerminal("S1", "1.90.7", now.Add(-40*day)),
+			want:     true,
+		},
+		{
+			name:     "active terminal",
+			query:    "lastActivity>30d",
+			terminal: newTerminal("S1", "1.90.7", now.Add(-day)),
+			want:     false,
+		},
+		{
+			name:     "active after the date",
+			query:    "lastActivity>2024-01-02",
+			terminal: newTerminal("S1", "1.90.7", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)),
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSelector(tt.query)
+			if err != nil {
+				t.Fatalf("ParseSelector(%q) error = %v", tt.query, err)
+			}
+			// Adyen applies the filter, the test checks the store of the first condition instead.
+			filter := s.filter()
+			if len(filter.StoreIDs) > 0 && !contains(filter.StoreIDs, tt.terminal.Assignment.StoreID) {
+				t.Fatalf("the terminal doesn't match the filter %+v", filter)
+			}
+			if got := s.match(tt.terminal); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirmwareVersion(t *testing.T) {
+	tests := []struct {
+		firmware string
+		want     string
+	}{
+		{firmware: "Castles_Android 1.90.7", want: "1.90.7"},
+		{firmware: "1.90.7", want: "1.90.7"},
+		{firmware: "Castles_Android", want: "Castles_Android"},
+		{firmware: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.firmware, func(t *testing.T) {
+			if got := FirmwareVersion(tt.firmware); got != tt.want {
+				t.Errorf("FirmwareVersion(%q) = %q, want %q", tt.firmware, got, tt.want)
+			}
+		})
+	}
+}
+
+func newTerminal(storeID, firmware string, lastActivityAt time.Time) *adyen.Terminal {
+	var terminal adyen.Terminal
+	terminal.Assignment.StoreID = storeID
+	terminal.Assignment.Status = "boarded"
+	terminal.FirmwareVersion = firmware
+	terminal.LastActivityAt = lastActivityAt
+	return &terminal
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package logo
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	// Register decoders of the supported logo formats.
+	_ "image/jpeg"
+	_ "image/png"
+	"sort"
+	"strings"
+)
+
+// MaxSize limits the size of the logo file in bytes.
+const MaxSize = 1024 * 1024
+
+// Dimensions declare the maximum logo dimensions of the terminal model in pixels.
+type Dimensions struct {
+	Width  int
+	Height int
+}
+
+// dimensions declare the maximum logo dimensions per terminal model, as Adyen documents them.
+var dimensions = map[string]Dimensions{
+	"AMS1":       {Width: 720, Height: 1280},
+	"E280":       {Width: 320, Height: 240},
+	"E285":       {Width: 320, Height: 240},
+	"E285P":      {Width: 320, Height: 480},
+	"E355":       {Width: 320, Height: 240},
+	"P400PLUS":   {Width: 320, Height: 480},
+	"S1E":        {Width: 720, Height: 1280},
+	"S1E2L":      {Width: 720, Height: 1280},
+	"S1F2":       {Width: 720, Height: 1280},
+	"S1U":        {Width: 720, Height: 1280},
+	"V240MPLUS":  {Width: 320, Height: 480},
+	"V400CPLUS":  {Width: 480, Height: 800},
+	"V400M":      {Width: 480, Height: 800},
+	"V660PPLUS":  {Width: 720, Height: 1280},
+	"V660PMPLUS": {Width: 720, Height: 1280},
+}
+
+// Validate checks the logo is PNG or JPG and fits the size and the dimensions of the terminal model.
+func Validate(model string, buf []byte) error {
+	maximum, ok := dimensions[normalize(model)]
+	if !ok {
+		return fmt.Errorf("unsupported terminal model %q, supported models: %s", model, strings.Join(Models(), ", "))
+	}
+	if len(buf) == 0 {
+		return fmt.Errorf("logo is empty")
+	}
+	if len(buf) > MaxSize {
+		return fmt.Errorf("logo is %d bytes, up to %d bytes are supported", len(buf), MaxSize)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		return fmt.Errorf("failed to decode logo, only PNG and JPG are supported: %w", err)
+	}
+	if format != "png" && format != "jpeg" {
+		return fmt.Errorf("logo format %s is not supported, only PNG and JPG are supported", format)
+	}
+	if config.Width > maximum.Width || config.Height > maximum.Height {
+		return fmt.Errorf("logo is %dx%d, %s supports up to %dx%d",
+			config.Width, config.Height, model, maximum.Width, maximum.Height)
+	}
+	return nil
+}
+
+// Extension returns the file extension of the logo by its content.
+func Extension(buf []byte) string {
+	_, format, err := image.DecodeConfig(bytes.NewReader(buf))
+	switch {
+	case err != nil:
+		return ".bin"
+	case format == "jpeg":
+		return ".jpg"
+	default:
+		return "." + format
+	}
+}
+
+// Models returns supported terminal models, sorted.
+func Models() []string {
+	models := make([]string, 0, len(dimensions))
+	for model := range dimensions {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// normalize makes the model comparable: Adyen uses both P400Plus and P400 Plus spelling.
+func normalize(model string) string {
+	return strings.ToUpper(strings.ReplaceAll(model, " ", ""))
+}
@@ -0,0 +1,129 @@
+package resolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheEntry declare one cached value with its expiration time.
+type cacheEntry struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// cache declare in-process memo, optionally persisted to the file.
+type cache struct {
+	mu      sync.Mutex
+	path    string
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+// newCache creates the cache and loads not expired entries from the file, if the path defined.
+func newCache(path string, ttl time.Duration) (*cache, error) {
+	c := &cache{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var entries map[string]cacheEntry
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return c, fmt.Errorf("failed to unmarshal cache: %w", err)
+	}
+	now := time.Now()
+	for key, entry := range entries {
+		if entry.ExpiresAt.After(now) {
+			c.entries[key] = entry
+		}
+	}
+	return c, nil
+}
+
+// get unmarshal the cached value into v, returns false if there is no such value.
+func (c *cache) get(key string, v interface{}) bool {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(entry.Value, v) == nil
+}
+
+// set caches the value.
+func (c *cache) set(key string, v interface{}) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{
+		Value:     buf,
+		ExpiresAt: time.Now().Add(c.ttl),
+	}
+	c.dirty = true
+}
+
+// delete removes the cached value.
+func (c *cache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		c.dirty = true
+	}
+}
+
+// save writes the cache to the file, if the path defined and there are any changes.
+func (c *cache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	buf, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	// Write to the temporary file first, so the interrupted run doesn't break the cache.
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
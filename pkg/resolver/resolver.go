@@ -0,0 +1,278 @@
+package resolver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+)
+
+const (
+	// PrefetchThreshold defines the number of records, starting from which the whole lists are prefetched.
+	PrefetchThreshold = 50
+
+	pageSize = 100
+
+	namespaceLive = "live"
+	namespaceTest = "test"
+)
+
+var (
+	// ErrInvalidResponse means we have a wrong Adyen response.
+	ErrInvalidResponse = errors.New("expected exactly one entity in Adyen response")
+	// ErrAmbiguousStore means several stores have the same reference.
+	ErrAmbiguousStore = errors.New("several stores have the same reference")
+)
+
+// Resolver declare the shared resolver of Adyen identifiers.
+// Resolved values are memoized in process and optionally persisted to the cache file.
+type Resolver struct {
+	logger    *zap.Logger
+	adyenAPI  *adyen.API
+	cache     *cache
+	namespace string
+
+	mu        sync.Mutex
+	ambiguous map[string]bool
+}
+
+// New creates new instance of Resolver.
+func New(logger *zap.Logger, adyenAPI *adyen.API, config *commands.Config, production bool) *Resolver {
+	c, err := newCache(config.CacheFile, config.CacheTTL)
+	if err != nil {
+		logger.
+			With(zap.String("CacheFile", config.CacheFile)).
+			With(zap.Error(err)).
+			Warn("Failed to load cache, starting with the empty one")
+	}
+
+	namespace, mgmtURL, mgmtKey := namespaceTest, config.AdyenMgmtTestURL, config.AdyenMgmtTestKey
+	if production {
+		namespace, mgmtURL, mgmtKey = namespaceLive, config.AdyenMgmtURL, config.AdyenMgmtKey
+	}
+	return &Resolver{
+		logger:    logger,
+		adyenAPI:  adyenAPI,
+		cache:     c,
+		namespace: namespace + "/" + account(mgmtURL, mgmtKey),
+		ambiguous: make(map[string]bool),
+	}
+}
+
+// Save persists resolved values to the cache file.
+func (r *Resolver) Save() {
+	if err := r.cache.save(); err != nil {
+		r.logger.
+			With(zap.Error(err)).
+			Warn("Failed to save cache")
+	}
+}
+
+// PrefetchStores pages through all stores, if the number of records is large enough.
+// References shared by several stores are not cached, Store returns ErrAmbiguousStore for them.
+func (r *Resolver) PrefetchStores(ctx context.Context, count int) {
+	if count < PrefetchThreshold {
+		return
+	}
+
+	var prefetched int
+	seen := make(map[string]bool)
+	for pageNumber, pagesTotal := 1, 1; pageNumber <= pagesTotal; pageNumber++ {
+		stores, err := r.adyenAPI.Stores(ctx, pageNumber, pageSize)
+		if err != nil {
+			r.logger.
+				With(zap.Error(err)).
+				Warn("Failed to prefetch stores, falling back to one by one lookups")
+			return
+		}
+		for i := range stores.Data {
+			reference := stores.Data[i].Reference
+			if seen[reference] {
+				r.setAmbiguous(reference)
+			}
+			seen[reference] = true
+			r.cache.set(r.key("store", reference), &stores.Data[i])
+			r.cache.set(r.key("storeID", stores.Data[i].ID), &stores.Data[i])
+		}
+		prefetched += len(stores.Data)
+		pagesTotal = stores.PagesTotal
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for reference := range r.ambiguous {
+		r.cache.delete(r.key("store", reference))
+		r.logger.
+			With(zap.String("StoreID", reference)).
+			Warn("Several stores have the same reference")
+	}
+
+	r.logger.
+		With(zap.Int("Count", prefetched)).
+		Info("Prefetched stores")
+}
+
+// PrefetchTerminals pages through all terminals, if the number of records is large enough.
+func (r *Resolver) PrefetchTerminals(ctx context.Context, count int) {
+	if count < PrefetchThreshold {
+		return
+	}
+
+	var prefetched int
+	for pageNumber, pagesTotal := 1, 1; pageNumber <= pagesTotal; pageNumber++ {
+		terminals, err := r.adyenAPI.Terminals(ctx, nil, pageNumber, pageSize)
+		if err != nil {
+			r.logger.
+				With(zap.Error(err)).
+				Warn("Failed to prefetch terminals, falling back to one by one lookups")
+			return
+		}
+		for i := range terminals.Data {
+			r.cache.set(r.key("terminal", terminals.Data[i].SerialNumber), terminals.Data[i].ID)
+			r.cache.set(r.key("terminalModel", terminals.Data[i].ID), terminals.Data[i].Model)
+		}
+		prefetched += len(terminals.Data)
+		pagesTotal = terminals.PagesTotal
+	}
+
+	r.logger.
+		With(zap.Int("Count", prefetched)).
+		Info("Prefetched terminals")
+}
+
+// Store resolves the store by its reference.
+func (r *Resolver) Store(ctx context.Context, reference string) (*adyen.GetStoreResponse, error) {
+	if r.isAmbiguous(reference) {
+		return nil, fmt.Errorf("%w: %s", ErrAmbiguousStore, reference)
+	}
+	key := r.key("store", reference)
+
+	var store adyen.GetStoreResponse
+	if r.cache.get(key, &store) {
+		return &store, nil
+	}
+
+	stores, err := r.adyenAPI.SearchStores(ctx, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all stores: %w", err)
+	}
+	if stores.ItemsTotal != 1 || len(stores.Data) != 1 {
+		return nil, ErrInvalidResponse
+	}
+	if stores.Data[0].Reference != reference {
+		return nil, fmt.Errorf("store ID not found: %s %s", stores.Data[0].Reference, reference)
+	}
+
+	r.cache.set(key, &stores.Data[0])
+	r.cache.set(r.key("storeID", stores.Data[0].ID), &stores.Data[0])
+	return &stores.Data[0], nil
+}
+
+// StoreByID resolves the store by its management ID.
+func (r *Resolver) StoreByID(ctx context.Context, storeMgmtID string) (*adyen.GetStoreResponse, error) {
+	key := r.key("storeID", storeMgmtID)
+
+	var store adyen.GetStoreResponse
+	if r.cache.get(key, &store) {
+		return &store, nil
+	}
+
+	s, err := r.adyenAPI.Store(ctx, storeMgmtID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store (%s): %w", storeMgmtID, err)
+	}
+
+	r.cache.set(key, s)
+	return s, nil
+}
+
+// TerminalID resolves the terminal ID by its serial number.
+func (r *Resolver) TerminalID(ctx context.Context, serial string) (string, error) {
+	key := r.key("terminal", serial)
+
+	var terminalID string
+	if r.cache.get(key, &terminalID) {
+		return terminalID, nil
+	}
+
+	terminals, err := r.adyenAPI.SearchTerminals(ctx, "", serial)
+	if err != nil {
+		return "", fmt.Errorf("failed to process terminals: %w", err)
+	}
+	if terminals.ItemsTotal != 1 || len(terminals.Data) != 1 {
+		return "", fmt.Errorf("expected 1 terminal, got %d", terminals.ItemsTotal)
+	}
+
+	r.cache.set(key, terminals.Data[0].ID)
+	return terminals.Data[0].ID, nil
+}
+
+// TerminalModel resolves the model of the terminal by its ID.
+func (r *Resolver) TerminalModel(ctx context.Context, terminalID string) (string, error) {
+	key := r.key("terminalModel", terminalID)
+
+	var model string
+	if r.cache.get(key, &model) {
+		return model, nil
+	}
+
+	terminals, err := r.adyenAPI.SearchTerminals(ctx, "", terminalID)
+	if err != nil {
+		return "", fmt.Errorf("failed to process terminals: %w", err)
+	}
+	for i := range terminals.Data {
+		if terminals.Data[i].ID == terminalID {
+			r.cache.set(key, terminals.Data[i].Model)
+			return terminals.Data[i].Model, nil
+		}
+	}
+	return "", fmt.Errorf("terminal not found: %s", terminalID)
+}
+
+// AccountHolder resolves the balance account holder by its ID.
+func (r *Resolver) AccountHolder(
+	ctx context.Context, accountHolderID string,
+) (*adyen.GetBalanceAccountHolderResponse, error) {
+	key := r.key("accountHolder", accountHolderID)
+
+	var accountHolder adyen.GetBalanceAccountHolderResponse
+	if r.cache.get(key, &accountHolder) {
+		return &accountHolder, nil
+	}
+
+	acc, err := r.adyenAPI.BalanceAccountHolder(ctx, accountHolderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance account holder (%s): %w", accountHolderID, err)
+	}
+
+	r.cache.set(key, acc)
+	return acc, nil
+}
+
+func (r *Resolver) key(kind, id string) string {
+	return r.namespace + "/" + kind + "/" + id
+}
+
+func (r *Resolver) setAmbiguous(reference string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ambiguous[reference] = true
+}
+
+func (r *Resolver) isAmbiguous(reference string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ambiguous[reference]
+}
+
+// account returns the short hash of the management API URL and key, so cached values of different accounts don't mix.
+func account(mgmtURL, mgmtKey string) string {
+	sum := sha256.Sum256([]byte(mgmtURL + "\n" + mgmtKey))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,309 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
+)
+
+// storesPrefix declare the prefix of the wave, which selects terminals by stores, like `stores:REF1;REF2`.
+const storesPrefix = "stores:"
+
+var (
+	// ErrInvalidWave means the wave of the rollout plan can't be parsed.
+	ErrInvalidWave = errors.New("invalid wave")
+	// ErrHalted means the wave failed on more terminals than allowed.
+	ErrHalted = errors.New("rollout halted")
+	// ErrAborted means the rollout was aborted.
+	ErrAborted = errors.New("rollout aborted")
+)
+
+// Plan splits terminals into waves. Every wave takes terminals, not taken by previous waves:
+//   - `N%`: N percent of all terminals;
+//   - `N`: N terminals;
+//   - `stores:REF1;REF2`: all terminals of the stores.
+//
+// Terminals, not taken by any wave, go to the last extra wave.
+func Plan(path string, specs []string, targets []Target, failureThreshold float64) (*State, error) {
+	state := &State{
+		Status:           StatusRunning,
+		FailureThreshold: failureThreshold,
+		path:             path,
+	}
+
+	rest := targets
+	for _, spec := range specs {
+		var wave []Target
+		var err error
+		wave, rest, err = take(spec, rest, len(targets))
+		if err != nil {
+			return nil, err
+		}
+		if len(wave) > 0 {
+			state.Waves = append(state.Waves, &Wave{Spec: spec, Targets: wave})
+		}
+	}
+	if len(rest) > 0 {
+		state.Waves = append(state.Waves, &Wave{Spec: "rest", Targets: rest})
+	}
+	return state, nil
+}
+
+// take splits terminals into the wave and the rest.
+func take(spec string, targets []Target, total int) (wave, rest []Target, err error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, storesPrefix) {
+		stores := make(map[string]bool)
+		for _, store := range strings.FieldsFunc(strings.TrimPrefix(spec, storesPrefix), func(r rune) bool {
+			return r == ';' || r == ' '
+		}) {
+			stores[store] = true
+		}
+		if len(stores) == 0 {
+			return nil, nil, fmt.Errorf("%w: %q has no stores", ErrInvalidWave, spec)
+		}
+		for i := range targets {
+			if stores[targets[i].StoreID] {
+				wave = append(wave, targets[i])
+			} else {
+				rest = append(rest, targets[i])
+			}
+		}
+		return wave, rest, nil
+	}
+
+	count, err := size(spec, total)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count > len(targets) {
+		count = len(targets)
+	}
+	return targets[:count], targets[count:], nil
+}
+
+// size returns the number of terminals in the wave, defined by the percentage or the count.
+func size(spec string, total int) (int, error) {
+	if percentage, ok := strings.CutSuffix(spec, "%"); ok {
+		value, err := strconv.ParseFloat(percentage, 64)
+		if err != nil || value <= 0 || value > 100 {
+			return 0, fmt.Errorf("%w: %q is not the percentage", ErrInvalidWave, spec)
+		}
+		return int(math.Ceil(value * float64(total) / 100)), nil
+	}
+
+	count, err := strconv.Atoi(spec)
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("%w: %q is not the percentage, the count or the list of stores", ErrInvalidWave, spec)
+	}
+	return count, nil
+}
+
+// Run runs waves one by one, starting from the current one. Every wave is scheduled after the previous one succeeded.
+// The rollout is halted, if the share of failed terminals of the wave is above the threshold.
+// If actions of the wave are not finished in time, the rollout can be resumed later with the same state.
+// The state file is checked before every wave, so `rollout abort` stops the running rollout.
+func Run(
+	ctx context.Context, logger *zap.Logger, adyenAPI *adyen.API, state *State, timeout time.Duration,
+) ([]*schedule.Action, error) {
+	var actions []*schedule.Action
+	for {
+		if err := state.Reload(); err != nil {
+			return actions, err
+		}
+		if state.Status != StatusRunning || state.Wave >= len(state.Waves) {
+			break
+		}
+
+		wave := state.Waves[state.Wave]
+		waveLogger := logger.
+			With(zap.Int("Wave", state.Wave+1)).
+			With(zap.Int("Waves", len(state.Waves))).
+			With(zap.String("Spec", wave.Spec)).
+			With(zap.Int("Terminals", len(wave.Targets)))
+
+		if !wave.Scheduled {
+			waveLogger.Info("Scheduling rollout wave")
+			scheduled, err := scheduleWave(ctx, logger, adyenAPI, wave)
+			if err != nil {
+				return actions, err
+			}
+			wave.Actions = scheduled
+			wave.Scheduled = true
+			if err := state.Save(); err != nil {
+				return actions, err
+			}
+		}
+		actions = append(actions, wave.Actions...)
+
+		waveLogger.Info("Waiting for rollout wave")
+		waitErr := schedule.Wait(ctx, logger, adyenAPI, wave.Actions, timeout)
+		if err := state.Save(); err != nil {
+			return actions, errors.Join(waitErr, err)
+		}
+		if state.Status == StatusAborted {
+			break
+		}
+		// Failed actions are checked against the threshold, other errors stop the rollout till it's resumed
+		if waitErr != nil && !errors.Is(waitErr, commands.ErrPartialFailure) && !errors.Is(waitErr, commands.ErrTotalFailure) {
+			return actions, waitErr
+		}
+		if err := next(waveLogger, state, wave); err != nil {
+			return actions, errors.Join(err, state.Save())
+		}
+	}
+	if state.Status == StatusRunning {
+		state.Status = StatusCompleted
+		logger.Info("Rollout completed")
+	}
+	saveErr := state.Save()
+	return actions, errors.Join(result(state), saveErr)
+}
+
+// result returns the error, if the rollout is not completed.
+// The halted rollout failed partially, if any terminal got the app before, and totally otherwise.
+func result(state *State) error {
+	switch state.Status {
+	case StatusAborted:
+		return fmt.Errorf("%w: %w at wave %d", commands.ErrInterrupted, ErrAborted, state.Wave+1)
+	case StatusHalted:
+		if successful(state) > 0 {
+			return fmt.Errorf("%w: %w at wave %d", commands.ErrPartialFailure, ErrHalted, state.Wave+1)
+		}
+		return fmt.Errorf("%w: %w at wave %d", commands.ErrTotalFailure, ErrHalted, state.Wave+1)
+	default:
+		return nil
+	}
+}
+
+// successful returns the number of terminals, which got the app in all waves scheduled so far.
+func successful(state *State) int {
+	var count int
+	for _, wave := range state.Waves {
+		for _, action := range wave.Actions {
+			if action.Status == adyen.ActionStatusSuccessful {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// group declare terminals of the wave, which are scheduled together: the same app at the same time.
+type group struct {
+	companyID, appID, at string
+}
+
+// scheduleWave schedules the action on terminals of the wave, grouped by the company, the app and the date,
+// up to schedule.MaxTerminalsPerAction terminals per request.
+// Terminals, which Adyen failed to schedule the action on, are counted as failed.
+func scheduleWave(ctx context.Context, logger *zap.Logger, adyenAPI *adyen.API, wave *Wave) ([]*schedule.Action, error) {
+	groups, terminalIDs, err := groupWave(wave)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []*schedule.Action
+	for _, g := range groups {
+		details := adyen.ActionDetails{Type: adyen.ActionInstallAndroidApp, AppID: g.appID}
+		for _, chunk := range schedule.Chunks(terminalIDs[g]) {
+			scheduled, err := schedule.Schedule(ctx, logger, adyenAPI, g.companyID, details, "", chunk, g.at)
+			if err != nil {
+				logger.
+					With(zap.String("CompanyID", g.companyID)).
+					With(zap.String("AppID", g.appID)).
+					With(zap.String("ScheduledAt", g.at)).
+					With(zap.Error(err)).
+					Error("Failed to schedule rollout wave")
+			}
+			actions = append(actions, scheduled...)
+		}
+	}
+	return actions, nil
+}
+
+// groupWave groups terminals of the wave, the order of terminals is kept.
+// Dates are resolved before anything is scheduled, targets without the date are scheduled now.
+func groupWave(wave *Wave) ([]group, map[group][]string, error) {
+	var groups []group
+	terminalIDs := make(map[group][]string)
+	dates := make(map[[2]string]string)
+	for i := range wave.Targets {
+		key := [2]string{wave.Targets[i].Date, wave.Targets[i].Timezone}
+		at, ok := dates[key]
+		if !ok {
+			var err error
+			if at, err = resolveDate(wave.Targets[i].Date, wave.Targets[i].Timezone); err != nil {
+				return nil, nil, err
+			}
+			dates[key] = at
+		}
+
+		g := group{companyID: wave.Targets[i].CompanyID, appID: wave.Targets[i].AppID, at: at}
+		if _, ok := terminalIDs[g]; !ok {
+			groups = append(groups, g)
+		}
+		terminalIDs[g] = append(terminalIDs[g], wave.Targets[i].TerminalID)
+	}
+	return groups, terminalIDs, nil
+}
+
+// resolveDate returns the date to schedule the installation at, the local date is in the timezone.
+func resolveDate(date, timezone string) (string, error) {
+	var location *time.Location
+	if timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return "", fmt.Errorf("failed to load timezone %q: %w", timezone, err)
+		}
+	}
+	at, err := schedule.At(date, location)
+	if err != nil {
+		return "", fmt.Errorf("failed to get schedule date: %w", err)
+	}
+	return at, nil
+}
+
+// next checks the result of the wave and moves the rollout to the next one, or halts it.
+func next(logger *zap.Logger, state *State, wave *Wave) error {
+	var successful, pending int
+	for _, action := range wave.Actions {
+		switch {
+		case action.Status == adyen.ActionStatusSuccessful:
+			successful++
+		case !action.IsFinal():
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: wave %d has %d terminal actions not finished, resume the rollout later",
+			commands.ErrPartialFailure, state.Wave+1, pending)
+	}
+
+	failed := len(wave.Targets) - successful
+	failureRate := float64(failed) * 100 / float64(len(wave.Targets))
+	logger = logger.
+		With(zap.Int("Successful", successful)).
+		With(zap.Int("Failed", failed)).
+		With(zap.Float64("FailureRate", failureRate)).
+		With(zap.Float64("FailureThreshold", state.FailureThreshold))
+	if failureRate > state.FailureThreshold {
+		logger.Error("Rollout wave failed, halting the rollout")
+		state.Status = StatusHalted
+		return nil
+	}
+
+	logger.Info("Rollout wave succeeded")
+	state.Wave++
+	return nil
+}
@@ -0,0 +1,144 @@
+package rollout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Toshik1978/csv2adyen/pkg/schedule"
+)
+
+// Rollout statuses.
+
+const (
+	StatusRunning   = "running"
+	StatusHalted    = "halted"
+	StatusAborted   = "aborted"
+	StatusCompleted = "completed"
+)
+
+// Target declare one terminal to install the app on.
+type Target struct {
+	CompanyID  string `json:"companyId"`
+	StoreID    string `json:"storeId,omitempty"`
+	TerminalID string `json:"terminalId"`
+	AppID      string `json:"appId"`
+	// Date declare the date to schedule the installation at, as it's defined in CSV. It's resolved, when the wave starts,
+	// so the time of day is the next one after the start. The empty date means the start of the wave.
+	Date string `json:"date,omitempty"`
+	// Timezone declare the location of the local date, UTC if empty.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// Wave declare one wave of the rollout: its terminals and actions scheduled on them.
+// Terminals, which Adyen failed to schedule the action on, have no actions.
+type Wave struct {
+	Spec      string             `json:"spec"`
+	Targets   []Target           `json:"targets"`
+	Scheduled bool               `json:"scheduled"`
+	Actions   []*schedule.Action `json:"actions,omitempty"`
+}
+
+// State declare the persistent state of the rollout, it's saved after every step, so the rollout can be resumed.
+type State struct {
+	Status           string    `json:"status"`
+	FailureThreshold float64   `json:"failureThreshold"`
+	Wave             int       `json:"wave"`
+	Waves            []*Wave   `json:"waves"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+
+	path string
+}
+
+// Load reads the state from the file, returns nil if there is no such file.
+func Load(path string) (*State, error) {
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rollout state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(buf, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rollout state: %w", err)
+	}
+	state.path = path
+	return &state, nil
+}
+
+// Save writes the state to the file.
+// The abort, written to the file by another process, is kept, see Reload.
+func (s *State) Save() error {
+	if err := s.Reload(); err != nil {
+		return err
+	}
+	s.UpdatedAt = time.Now()
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollout state: %w", err)
+	}
+
+	// Write to the temporary file first, so the interrupted run doesn't break the state.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create rollout state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write rollout state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write rollout state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write rollout state: %w", err)
+	}
+	return nil
+}
+
+// Reload picks up the abort, written to the file by `rollout abort`, while the rollout runs.
+func (s *State) Reload() error {
+	saved, err := Load(s.path)
+	if err != nil {
+		return err
+	}
+	if saved != nil && saved.Status == StatusAborted {
+		s.Status = StatusAborted
+	}
+	return nil
+}
+
+// Abort marks the rollout aborted, no more waves are scheduled.
+// Actions, which are already scheduled, are not cancelled.
+func Abort(path string) (*State, error) {
+	state, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no rollout state found: %s", path)
+	}
+	if state.Status == StatusCompleted {
+		return state, fmt.Errorf("rollout is already completed")
+	}
+	state.Status = StatusAborted
+	return state, state.Save()
+}
+
+// Options declare the rollout plan and the file to keep its state in.
+type Options struct {
+	// StatePath defines the state file, the rollout is used only if it's defined.
+	StatePath string
+	// Waves defines waves of the new rollout, they are ignored, if the rollout is resumed.
+	Waves []string
+	// FailureThreshold defines the share of failed terminals in percent, which halts the rollout.
+	FailureThreshold float64
+}
@@ -0,0 +1,244 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+)
+
+const (
+	// pollInterval defines how often statuses of terminal actions are checked.
+	pollInterval = 10 * time.Second
+	// maxFailedPolls defines how many polls in a row may fail for all actions, before the wait is stopped.
+	maxFailedPolls = 3
+)
+
+// Action declare one scheduled action on one terminal and its last known status.
+// Row refers to CSV row, which the action was scheduled for, if it's known.
+type Action struct {
+	Row         int    `csv:"ROW" json:"row,omitempty"`
+	CompanyID   string `csv:"COMPANY ID" json:"companyId"`
+	ID          string `csv:"ACTION ID" json:"id"`
+	TerminalID  string `csv:"TERMINAL ID" json:"terminalId"`
+	Type        string `csv:"TYPE" json:"type"`
+	ScheduledAt string `csv:"SCHEDULED AT" json:"scheduledAt"`
+	Status      string `csv:"STATUS" json:"status"`
+	Result      string `csv:"RESULT" json:"result,omitempty"`
+}
+
+// IsFinal checks if the action is finished: succeeded, failed or cancelled.
+func (a *Action) IsFinal() bool {
+	switch a.Status {
+	case adyen.ActionStatusSuccessful, adyen.ActionStatusFailed, adyen.ActionStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait polls statuses of actions till all of them are finished or the timeout expires.
+// Statuses are updated in place, the final state of every terminal is logged.
+// Returns the error, if any action failed or is not finished.
+func Wait(ctx context.Context, logger *zap.Logger, adyenAPI *adyen.API, actions []*Action, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var failedPolls int
+	for {
+		pending, err := refresh(ctx, logger, adyenAPI, actions)
+		switch {
+		case errors.Is(err, commands.ErrInterrupted):
+			return err
+		case err != nil:
+			failedPolls++
+			if failedPolls >= maxFailedPolls {
+				return err
+			}
+		default:
+			failedPolls = 0
+		}
+		if err == nil && pending == 0 {
+			return result(actions)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %d terminal actions not finished", commands.ErrInterrupted, pending)
+		case <-deadline.C:
+			logPending(logger, actions)
+			return result(actions)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh gets statuses of actions once, statuses are updated in place.
+// Returns the error, if any action failed or is not finished.
+func Refresh(ctx context.Context, logger *zap.Logger, adyenAPI *adyen.API, actions []*Action) error {
+	if _, err := refresh(ctx, logger, adyenAPI, actions); err != nil {
+		return err
+	}
+	logPending(logger, actions)
+	return result(actions)
+}
+
+// refresh updates statuses of not finished actions and returns the number of them, which are still not finished.
+// Actions, which status failed to get, are kept not finished till the next poll.
+// Returns the error, if the context is cancelled or statuses of all actions failed to get.
+func refresh(ctx context.Context, logger *zap.Logger, adyenAPI *adyen.API, actions []*Action) (int, error) {
+	var pending, polled, failed int
+	var lastErr error
+	for _, action := range actions {
+		if action.IsFinal() {
+			continue
+		}
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("%w: %d terminal actions not finished", commands.ErrInterrupted, notFinished(actions))
+		}
+
+		polled++
+		current, err := adyenAPI.TerminalAction(ctx, action.CompanyID, action.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, fmt.Errorf("%w: %d terminal actions not finished", commands.ErrInterrupted, notFinished(actions))
+			}
+			logger.
+				With(zap.String("ActionID", action.ID)).
+				With(zap.String("TerminalID", action.TerminalID)).
+				With(zap.Error(err)).
+				Warn("Failed to get terminal action status")
+			pending++
+			failed++
+			lastErr = err
+			continue
+		}
+		action.Status = current.Status
+		action.Result = current.Result
+		if action.TerminalID == "" {
+			action.TerminalID = current.TerminalID
+		}
+		if action.Type == "" {
+			action.Type = current.ActionType
+		}
+
+		actionLogger := logger.
+			With(zap.String("ActionID", action.ID)).
+			With(zap.String("TerminalID", action.TerminalID)).
+			With(zap.String("Type", action.Type)).
+			With(zap.String("Status", action.Status)).
+			With(zap.String("Result", action.Result))
+		switch action.Status {
+		case adyen.ActionStatusSuccessful:
+			actionLogger.Info("Terminal action succeeded")
+		case adyen.ActionStatusFailed, adyen.ActionStatusCancelled:
+			actionLogger.Error("Terminal action failed")
+		default:
+			pending++
+		}
+	}
+	if failed > 0 && failed == polled {
+		return pending, fmt.Errorf("failed to get terminal action statuses: %w", lastErr)
+	}
+	return pending, nil
+}
+
+// notFinished returns the number of actions, which are not finished.
+func notFinished(actions []*Action) int {
+	var count int
+	for _, action := range actions {
+		if !action.IsFinal() {
+			count++
+		}
+	}
+	return count
+}
+
+func logPending(logger *zap.Logger, actions []*Action) {
+	for _, action := range actions {
+		if !action.IsFinal() {
+			logger.
+				With(zap.String("ActionID", action.ID)).
+				With(zap.String("TerminalID", action.TerminalID)).
+				With(zap.String("Type", action.Type)).
+				With(zap.String("Status", action.Status)).
+				Warn("Terminal action is not finished")
+		}
+	}
+}
+
+// result returns the error, if any action failed or is not finished.
+func result(actions []*Action) error {
+	var successful, failed int
+	for _, action := range actions {
+		switch action.Status {
+		case adyen.ActionStatusSuccessful:
+			successful++
+		case adyen.ActionStatusFailed, adyen.ActionStatusCancelled:
+			failed++
+		}
+	}
+
+	pending := len(actions) - successful - failed
+	switch {
+	case successful == len(actions):
+		return nil
+	case failed == len(actions):
+		return fmt.Errorf("%w: %d terminal actions failed", commands.ErrTotalFailure, failed)
+	case failed == 0:
+		// Nothing failed yet, actions may still succeed after the wait is over
+		return fmt.Errorf("%w: %d terminal actions not finished", commands.ErrPartialFailure, pending)
+	default:
+		return fmt.Errorf("%w: %d terminal actions failed, %d not finished", commands.ErrPartialFailure, failed, pending)
+	}
+}
+
+// WriteActions writes actions with their statuses to CSV, `actions status` accepts it as the input.
+func WriteActions(path string, actions []*Action) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create actions file: %w", err)
+	}
+	defer file.Close()
+	if err := gocsv.MarshalFile(&actions, file); err != nil {
+		return fmt.Errorf("failed to write actions file: %w", err)
+	}
+	return nil
+}
+
+// Tracking declare how scheduled actions are tracked.
+type Tracking struct {
+	// FilePath defines CSV file to write actions and their statuses to, nothing is written if empty.
+	FilePath string
+	// Wait defines if statuses are polled till actions are finished.
+	Wait bool
+	// Timeout defines how long to wait.
+	Timeout time.Duration
+}
+
+// Track waits for actions, if requested, and writes them to the file, if defined.
+func Track(ctx context.Context, logger *zap.Logger, adyenAPI *adyen.API, actions []*Action, tracking *Tracking) error {
+	var err error
+	if tracking.Wait && len(actions) > 0 && ctx.Err() == nil {
+		logger.
+			With(zap.Int("Count", len(actions))).
+			With(zap.Duration("Timeout", tracking.Timeout)).
+			Info("Waiting for terminal actions")
+		err = Wait(ctx, logger, adyenAPI, actions, tracking.Timeout)
+	}
+	if tracking.FilePath != "" {
+		if writeErr := WriteActions(tracking.FilePath, actions); writeErr != nil {
+			return errors.Join(err, writeErr)
+		}
+	}
+	return err
+}
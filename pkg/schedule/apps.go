@@ -0,0 +1,137 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/version"
+)
+
+// appsPageSize defines the page size to get android apps.
+const appsPageSize = 100
+
+// Version selectors, other than the exact version name.
+const (
+	VersionLatest     = "latest"
+	versionCodePrefix = "versionCode:"
+)
+
+// Apps declare android apps of companies, they are got once per company and package and shared across rows.
+type Apps struct {
+	adyenAPI *adyen.API
+
+	mu   sync.Mutex
+	apps map[string][]adyen.AndroidApp
+}
+
+// NewApps creates new instance of Apps.
+func NewApps(adyenAPI *adyen.API) *Apps {
+	return &Apps{
+		adyenAPI: adyenAPI,
+		apps:     make(map[string][]adyen.AndroidApp),
+	}
+}
+
+// List returns all versions of the package in the company library.
+func (a *Apps) List(ctx context.Context, companyID, packageName string) ([]adyen.AndroidApp, error) {
+	key := companyID + "/" + packageName
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if apps, ok := a.apps[key]; ok {
+		return apps, nil
+	}
+
+	var apps []adyen.AndroidApp
+	for pageNumber, pagesTotal := 1, 1; pageNumber <= pagesTotal; pageNumber++ {
+		page, err := a.adyenAPI.SearchAndroidApps(ctx, companyID, packageName, pageNumber, appsPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get all apps: %w", err)
+		}
+		apps = append(apps, page.Data...)
+		pagesTotal = page.PagesTotal
+	}
+
+	a.apps[key] = apps
+	return apps, nil
+}
+
+// ID finds the app version of the company. The version is selected by:
+//   - `latest`: the ready version with the highest version code;
+//   - `versionCode:N`: the version with the version code N;
+//   - the range, like `>=1.2 <2.0` or `1.2.x`: the ready version with the highest version code in the range;
+//   - the exact version name otherwise.
+func (a *Apps) ID(ctx context.Context, companyID, packageName, selector string) (string, error) {
+	apps, err := a.List(ctx, companyID, packageName)
+	if err != nil {
+		return "", err
+	}
+
+	match, err := versionMatcher(selector)
+	if err != nil {
+		return "", err
+	}
+	var found *adyen.AndroidApp
+	for i := range apps {
+		if match(&apps[i]) && (found == nil || apps[i].VersionCode > found.VersionCode) {
+			found = &apps[i]
+		}
+	}
+	if found == nil {
+		return "", fmt.Errorf("%w: %s %s, available versions: %s", ErrNoAppFound, packageName, selector, available(apps))
+	}
+	return found.ID, nil
+}
+
+// versionMatcher parses the version selector.
+func versionMatcher(selector string) (func(*adyen.AndroidApp) bool, error) {
+	selector = strings.TrimSpace(selector)
+	switch {
+	case strings.EqualFold(selector, VersionLatest):
+		return func(app *adyen.AndroidApp) bool {
+			return app.Status == adyen.AppStatusReady
+		}, nil
+	case strings.HasPrefix(selector, versionCodePrefix):
+		code, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(selector, versionCodePrefix)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version code %q: %w", selector, err)
+		}
+		return func(app *adyen.AndroidApp) bool {
+			return app.VersionCode == code
+		}, nil
+	case version.IsRange(selector):
+		versionRange, err := version.ParseRange(selector)
+		if err != nil {
+			return nil, err
+		}
+		return func(app *adyen.AndroidApp) bool {
+			return app.Status == adyen.AppStatusReady && versionRange.Match(app.VersionName)
+		}, nil
+	default:
+		return func(app *adyen.AndroidApp) bool {
+			return app.VersionName == selector
+		}, nil
+	}
+}
+
+// available formats all versions of the app, like `1.0.0 (1, ready), 1.1.0 (2, processing)`.
+func available(apps []adyen.AndroidApp) string {
+	if len(apps) == 0 {
+		return "none"
+	}
+
+	sorted := make([]adyen.AndroidApp, len(apps))
+	copy(sorted, apps)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].VersionCode < sorted[j].VersionCode
+	})
+	versions := make([]string, 0, len(sorted))
+	for i := range sorted {
+		versions = append(versions, fmt.Sprintf("%s (%d, %s)", sorted[i].VersionName, sorted[i].VersionCode, sorted[i].Status))
+	}
+	return strings.Join(versions, ", ")
+}
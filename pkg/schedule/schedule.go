@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+const (
+	// MaxTerminalsPerAction limits the number of terminals Adyen accepts in one schedule action request.
+	MaxTerminalsPerAction = 100
+
+	terminalsPageSize = 100
+)
+
+var (
+	// ErrNoAppFound means we could not find the relevant app version.
+	ErrNoAppFound = errors.New("no app found")
+	// ErrNoCertificateFound means we could not find the certificate.
+	ErrNoCertificateFound = errors.New("no certificate found")
+)
+
+// Terminals returns the terminal, or all terminals of the store, which match the search query.
+// Terminals of the store are paged through, so there is no limit on their number.
+func Terminals(
+	ctx context.Context, adyenAPI *adyen.API, resolver *resolver.Resolver, storeReference, searchQuery, terminalID string,
+) ([]string, error) {
+	if storeReference == "" {
+		if terminalID == "" {
+			return nil, fmt.Errorf("no store id and terminal id defined")
+		}
+		return []string{terminalID}, nil
+	}
+
+	// Need to convert Adyen Store GUID to the management ID.
+	store, err := resolver.Store(ctx, storeReference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store ID by UUID: %w", err)
+	}
+
+	filter := &adyen.TerminalsFilter{StoreIDs: []string{store.ID}, SearchQuery: searchQuery}
+	var terminalIDs []string
+	for pageNumber, pagesTotal := 1, 1; pageNumber <= pagesTotal; pageNumber++ {
+		terminals, err := adyenAPI.Terminals(ctx, filter, pageNumber, terminalsPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get terminals: %w", err)
+		}
+		for i := range terminals.Data {
+			terminalIDs = append(terminalIDs, terminals.Data[i].ID)
+		}
+		pagesTotal = terminals.PagesTotal
+	}
+	return terminalIDs, nil
+}
+
+// CertificateID finds the certificate of the company by its name.
+func CertificateID(ctx context.Context, adyenAPI *adyen.API, companyID, certificateName string) (string, error) {
+	certificates, err := adyenAPI.SearchAndroidCertificates(ctx, companyID, certificateName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get all certificates: %w", err)
+	}
+
+	for i := range certificates.Data {
+		if certificates.Data[i].Name == certificateName {
+			return certificates.Data[i].ID, nil
+		}
+	}
+	return "", ErrNoCertificateFound
+}
+
+// Chunks splits terminals into chunks, which Adyen accepts in one schedule action request, the order is kept.
+func Chunks(terminalIDs []string) [][]string {
+	var chunks [][]string
+	for start := 0; start < len(terminalIDs); start += MaxTerminalsPerAction {
+		end := start + MaxTerminalsPerAction
+		if end > len(terminalIDs) {
+			end = len(terminalIDs)
+		}
+		chunks = append(chunks, terminalIDs[start:end])
+	}
+	return chunks
+}
+
+// Schedule schedules the action on terminals and returns scheduled actions, one per terminal.
+// If the store is defined, all terminals must be assigned to it.
+// Terminals, which Adyen failed to schedule the action on, are logged and reported as the error,
+// actions on other terminals are returned anyway.
+func Schedule(
+	ctx context.Context, logger *zap.Logger, adyenAPI *adyen.API,
+	companyID string, details adyen.ActionDetails, storeID string, terminalIDs []string, at string,
+) ([]*Action, error) {
+	scheduled, err := adyenAPI.ScheduleAction(ctx, details, storeID, terminalIDs, at)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]*Action, 0, len(scheduled.Items))
+	for _, item := range scheduled.Items {
+		actions = append(actions, &Action{
+			CompanyID:   companyID,
+			ID:          item.ID,
+			TerminalID:  item.TerminalID,
+			Type:        details.Type,
+			ScheduledAt: at,
+			Status:      adyen.ActionStatusPending,
+		})
+	}
+	if scheduled.TotalErrors == 0 && len(scheduled.TerminalsWithErrors) == 0 {
+		return actions, nil
+	}
+
+	failedIDs := make([]string, 0, len(scheduled.TerminalsWithErrors))
+	for terminalID := range scheduled.TerminalsWithErrors {
+		failedIDs = append(failedIDs, terminalID)
+	}
+	sort.Strings(failedIDs)
+	for _, terminalID := range failedIDs {
+		logger.
+			With(zap.String("TerminalID", terminalID)).
+			With(zap.String("Type", details.Type)).
+			With(zap.Strings("Errors", scheduled.TerminalsWithErrors[terminalID])).
+			Error("Failed to schedule terminal action")
+	}
+	return actions, fmt.Errorf("failed to schedule %s on %d of %d terminals: %s",
+		details.Type, len(terminalIDs)-len(actions), len(terminalIDs), strings.Join(failedIDs, ", "))
+}
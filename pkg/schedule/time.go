@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// delay defines how long after now the action is scheduled, if the date is not defined.
+const delay = 2 * time.Minute
+
+// scheduledAtLayout declare the format of the date Adyen expects, the offset goes without colon.
+const scheduledAtLayout = "2006-01-02T15:04:05-0700"
+
+var (
+	// absoluteLayouts declare formats of dates with the offset.
+	absoluteLayouts = []string{scheduledAtLayout, time.RFC3339, "2006-01-02T15:04-0700", "2006-01-02T15:04Z07:00"}
+	// localLayouts declare formats of the local wall-clock dates.
+	localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+	// clockLayouts declare formats of the local time of day.
+	clockLayouts = []string{"15:04", "15:04:05"}
+)
+
+// ErrInvalidDate means the date can't be parsed.
+var ErrInvalidDate = errors.New("invalid date")
+
+// At returns the date to schedule the action at, formatted the way Adyen expects:
+//   - now + 2 minutes, if the date is empty;
+//   - the date with the offset, like `2024-01-02T03:00:00+01:00`, as is;
+//   - the wall-clock date, like `2024-01-02 03:00`, in the location;
+//   - the time of day, like `03:00`, the next such time in the location.
+//
+// The location is UTC, if it's not defined.
+func At(date string, location *time.Location) (string, error) {
+	if location == nil {
+		location = time.UTC
+	}
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return time.Now().Add(delay).In(location).Format(scheduledAtLayout), nil
+	}
+
+	for _, layout := range absoluteLayouts {
+		if at, err := time.Parse(layout, date); err == nil {
+			return at.Format(scheduledAtLayout), nil
+		}
+	}
+	for _, layout := range localLayouts {
+		if at, err := time.ParseInLocation(layout, date, location); err == nil {
+			return at.Format(scheduledAtLayout), nil
+		}
+	}
+	for _, layout := range clockLayouts {
+		if clock, err := time.Parse(layout, date); err == nil {
+			return next(clock, location).Format(scheduledAtLayout), nil
+		}
+	}
+	return "", fmt.Errorf("%w %q: expected the date with the offset, the local date or the time of day", ErrInvalidDate, date)
+}
+
+// next returns the next time of day in the location: today, if it's not passed yet, or tomorrow.
+func next(clock time.Time, location *time.Location) time.Time {
+	now := time.Now().In(location)
+	at := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, location)
+	if !at.After(now) {
+		at = time.Date(now.Year(), now.Month(), now.Day()+1, clock.Hour(), clock.Minute(), clock.Second(), 0, location)
+	}
+	return at
+}
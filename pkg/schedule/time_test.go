@@ -0,0 +1,127 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     string
+		location string
+		want     string
+	}{
+		{name: "offset", date: "2024-01-02T03:00:00+01:00", location: "America/New_York", want: "2024-01-02T03:00:00+0100"},
+		{name: "offset without colon", date: "2024-01-02T03:00:00+0100", want: "2024-01-02T03:00:00+0100"},
+		{name: "UTC", date: "2024-01-02T03:00Z", location: "Asia/Tokyo", want: "2024-01-02T03:00:00+0000"},
+		{name: "local without location", date: "2024-01-02 03:00", want: "2024-01-02T03:00:00+0000"},
+		{name: "local in winter", date: "2024-01-02 03:00", location: "America/New_York", want: "2024-01-02T03:00:00-0500"},
+		{name: "local in summer", date: "2024-07-02T03:00", location: "America/New_York", want: "2024-07-02T03:00:00-0400"},
+		{name: "local with seconds", date: " 2024-07-02 03:00:30 ", location: "Australia/Perth", want: "2024-07-02T03:00:30+0800"},
+		{name: "local in the other timezone of the country", date: "2024-07-02T03:00", location: "Australia/Sydney", want: "2024-07-02T03:00:00+1000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := At(tt.date, loadLocation(t, tt.location))
+			if err != nil {
+				t.Fatalf("At(%q) error = %v", tt.date, err)
+			}
+			if got != tt.want {
+				t.Errorf("At(%q) = %q, want %q", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAtClock(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     string
+		location string
+		hour     int
+		minute   int
+		second   int
+	}{
+		{name: "without location", date: "03:00", hour: 3},
+		{name: "in location", date: "23:30", location: "America/Los_Angeles", hour: 23, minute: 30},
+		{name: "with seconds", date: "12:15:45", location: "Asia/Kolkata", hour: 12, minute: 15, second: 45},
+		{name: "midnight", date: "00:00", location: "Pacific/Auckland"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := loadLocation(t, tt.location)
+			if location == nil {
+				location = time.UTC
+			}
+			now := time.Now()
+			got, err := At(tt.date, location)
+			if err != nil {
+				t.Fatalf("At(%q) error = %v", tt.date, err)
+			}
+			at, err := time.Parse(scheduledAtLayout, got)
+			if err != nil {
+				t.Fatalf("At(%q) = %q, not the date: %v", tt.date, got, err)
+			}
+
+			local := at.In(location)
+			if local.Hour() != tt.hour || local.Minute() != tt.minute || local.Second() != tt.second {
+				t.Errorf("At(%q) = %q, want %02d:%02d:%02d in %s", tt.date, got, tt.hour, tt.minute, tt.second, location)
+			}
+			if got != local.Format(scheduledAtLayout) {
+				t.Errorf("At(%q) = %q, want the offset of %s", tt.date, got, location)
+			}
+			// Daylight saving time shifts can make the day 25 hours long.
+			if !at.After(now) || at.Sub(now) > 25*time.Hour {
+				t.Errorf("At(%q) = %q, want the next such time after %s", tt.date, got, now.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestAtNow(t *testing.T) {
+	before := time.Now()
+	got, err := At("", loadLocation(t, "Europe/Berlin"))
+	if err != nil {
+		t.Fatalf("At() error = %v", err)
+	}
+	at, err := time.Parse(scheduledAtLayout, got)
+	if err != nil {
+		t.Fatalf("At() = %q, not the date: %v", got, err)
+	}
+	if at.Before(before.Add(delay).Truncate(time.Second)) || at.After(time.Now().Add(delay)) {
+		t.Errorf("At() = %q, want now + %s", got, delay)
+	}
+}
+
+func TestAtErrors(t *testing.T) {
+	tests := []string{
+		"tomorrow",
+		"25:00",
+		"3pm",
+		"2024-13-01 03:00",
+		"2024-01-02",
+		"2024-01-02T03:00:00+25:00",
+	}
+	for _, date := range tests {
+		t.Run(date, func(t *testing.T) {
+			if _, err := At(date, time.UTC); !errors.Is(err, ErrInvalidDate) {
+				t.Errorf("At(%q) error = %v, want %v", date, err, ErrInvalidDate)
+			}
+		})
+	}
+}
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	if name == "" {
+		return nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
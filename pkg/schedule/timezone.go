@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+// ErrNoTimezone means the timezone of the store or the terminal can't be derived.
+var ErrNoTimezone = errors.New("no timezone found")
+
+// countryTimezones declare timezones of countries, which have the only one.
+var countryTimezones = map[string]string{
+	"AE": "Asia/Dubai",
+	"AT": "Europe/Vienna",
+	"BE": "Europe/Brussels",
+	"BG": "Europe/Sofia",
+	"CH": "Europe/Zurich",
+	"CZ": "Europe/Prague",
+	"DE": "Europe/Berlin",
+	"DK": "Europe/Copenhagen",
+	"EE": "Europe/Tallinn",
+	"FI": "Europe/Helsinki",
+	"FR": "Europe/Paris",
+	"GB": "Europe/London",
+	"GR": "Europe/Athens",
+	"HK": "Asia/Hong_Kong",
+	"HR": "Europe/Zagreb",
+	"HU": "Europe/Budapest",
+	"IE": "Europe/Dublin",
+	"IL": "Asia/Jerusalem",
+	"IN": "Asia/Kolkata",
+	"IT": "Europe/Rome",
+	"JP": "Asia/Tokyo",
+	"KR": "Asia/Seoul",
+	"LT": "Europe/Vilnius",
+	"LU": "Europe/Luxembourg",
+	"LV": "Europe/Riga",
+	"MT": "Europe/Malta",
+	"NL": "Europe/Amsterdam",
+	"NO": "Europe/Oslo",
+	"PL": "Europe/Warsaw",
+	"RO": "Europe/Bucharest",
+	"SE": "Europe/Stockholm",
+	"SG": "Asia/Singapore",
+	"SI": "Europe/Ljubljana",
+	"SK": "Europe/Bratislava",
+	"TW": "Asia/Taipei",
+}
+
+// Timezones declare timezones of stores and terminals, they are resolved once and shared across rows.
+type Timezones struct {
+	adyenAPI *adyen.API
+	resolver *resolver.Resolver
+
+	mu        sync.Mutex
+	locations map[string]*time.Location
+}
+
+// NewTimezones creates new instance of Timezones.
+func NewTimezones(adyenAPI *adyen.API, resolver *resolver.Resolver) *Timezones {
+	return &Timezones{
+		adyenAPI:  adyenAPI,
+		resolver:  resolver,
+		locations: make(map[string]*time.Location),
+	}
+}
+
+// Store returns the timezone of the store: from its terminal settings,
+// or from the country of its address, if the country has the only timezone.
+func (t *Timezones) Store(ctx context.Context, storeReference string) (*time.Location, error) {
+	key := adyen.LevelStore + "/" + storeReference
+	if location, ok := t.cached(key); ok {
+		return location, nil
+	}
+
+	store, err := t.resolver.Store(ctx, storeReference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store ID by UUID: %w", err)
+	}
+	settings, err := t.adyenAPI.TerminalSettings(ctx, adyen.SettingsTarget{Level: adyen.LevelStore, ID: store.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store terminal settings: %w", err)
+	}
+
+	name := settings.Localization.Timezone
+	if name == "" {
+		name = countryTimezones[store.Address.Country]
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%w: store %s in %s", ErrNoTimezone, storeReference, store.Address.Country)
+	}
+	return t.load(key, name)
+}
+
+// Terminal returns the timezone of the terminal from its terminal settings.
+func (t *Timezones) Terminal(ctx context.Context, terminalID string) (*time.Location, error) {
+	key := adyen.LevelTerminal + "/" + terminalID
+	if location, ok := t.cached(key); ok {
+		return location, nil
+	}
+
+	settings, err := t.adyenAPI.TerminalSettings(ctx, adyen.SettingsTarget{Level: adyen.LevelTerminal, ID: terminalID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get terminal settings: %w", err)
+	}
+	if settings.Localization.Timezone == "" {
+		return nil, fmt.Errorf("%w: terminal %s", ErrNoTimezone, terminalID)
+	}
+	return t.load(key, settings.Localization.Timezone)
+}
+
+func (t *Timezones) cached(key string) (*time.Location, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	location, ok := t.locations[key]
+	return location, ok
+}
+
+func (t *Timezones) load(key, name string) (*time.Location, error) {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoTimezone, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.locations[key] = location
+	return location, nil
+}
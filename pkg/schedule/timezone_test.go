@@ -0,0 +1,163 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Toshik1978/csv2adyen/pkg/adyen"
+	"github.com/Toshik1978/csv2adyen/pkg/commands"
+	"github.com/Toshik1978/csv2adyen/pkg/resolver"
+)
+
+// testStore declare the store of the test server: its country and the timezone of its terminal settings.
+type testStore struct {
+	country  string
+	timezone string
+}
+
+func TestCountryTimezones(t *testing.T) {
+	// Countries with several timezones need the timezone in terminal settings.
+	for _, country := range []string{"US", "CA", "AU", "BR", "MX", "RU", "ES", "PT", "ID", "NZ"} {
+		if name, ok := countryTimezones[country]; ok {
+			t.Errorf("countryTimezones[%q] = %q, want no timezone", country, name)
+		}
+	}
+	for country, name := range countryTimezones {
+		if _, err := time.LoadLocation(name); err != nil {
+			t.Errorf("countryTimezones[%q] = %q: %v", country, name, err)
+		}
+	}
+}
+
+func TestTimezonesStore(t *testing.T) {
+	stores := map[string]testStore{
+		"ST-DE":       {country: "DE"},
+		"ST-US":       {country: "US"},
+		"ST-US-SET":   {country: "US", timezone: "America/Chicago"},
+		"ST-AU":       {country: "AU"},
+		"ST-AU-SET":   {country: "AU", timezone: "Australia/Perth"},
+		"ST-GB-SET":   {country: "GB", timezone: "Europe/Dublin"},
+		"ST-UNKNOWN":  {country: "ZZ"},
+		"ST-BAD-ZONE": {country: "US", timezone: "America/Nowhere"},
+	}
+	tests := []struct {
+		name    string
+		store   string
+		want    string
+		wantErr error
+	}{
+		{name: "country with the only timezone", store: "ST-DE", want: "Europe/Berlin"},
+		{name: "country with several timezones", store: "ST-US", wantErr: ErrNoTimezone},
+		{name: "country with several timezones, defined in settings", store: "ST-US-SET", want: "America/Chicago"},
+		{name: "other country with several timezones", store: "ST-AU", wantErr: ErrNoTimezone},
+		{name: "other country with several timezones, defined in settings", store: "ST-AU-SET", want: "Australia/Perth"},
+		{name: "settings go first", store: "ST-GB-SET", want: "Europe/Dublin"},
+		{name: "unknown country", store: "ST-UNKNOWN", wantErr: ErrNoTimezone},
+		{name: "unknown timezone", store: "ST-BAD-ZONE", wantErr: ErrNoTimezone},
+	}
+	timezones := newTestTimezones(t, stores, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := timezones.Store(context.Background(), tt.store)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Store(%q) error = %v, want %v", tt.store, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Store(%q) error = %v", tt.store, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Store(%q) = %s, want %s", tt.store, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimezonesTerminal(t *testing.T) {
+	terminals := map[string]string{
+		"S1F2-1": "America/Denver",
+		"S1F2-2": "",
+	}
+	tests := []struct {
+		name     string
+		terminal string
+		want     string
+		wantErr  error
+	}{
+		{name: "timezone in settings", terminal: "S1F2-1", want: "America/Denver"},
+		{name: "no timezone in settings", terminal: "S1F2-2", wantErr: ErrNoTimezone},
+	}
+	timezones := newTestTimezones(t, nil, terminals)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := timezones.Terminal(context.Background(), tt.terminal)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Terminal(%q) error = %v, want %v", tt.terminal, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Terminal(%q) error = %v", tt.terminal, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Terminal(%q) = %s, want %s", tt.terminal, got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestTimezones creates timezones, which get stores and terminal settings from the test server.
+// Store IDs are the references in lower case.
+func newTestTimezones(t *testing.T, stores map[string]testStore, terminals map[string]string) *Timezones {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case r.URL.Path == "/v3/stores":
+			reference := r.URL.Query().Get("reference")
+			store, ok := stores[reference]
+			if !ok {
+				writeJSON(w, map[string]interface{}{"itemsTotal": 0, "data": []interface{}{}})
+				return
+			}
+			writeJSON(w, map[string]interface{}{"itemsTotal": 1, "data": []interface{}{map[string]interface{}{
+				"id":        strings.ToLower(reference),
+				"reference": reference,
+				"address":   map[string]interface{}{"country": store.country},
+			}}})
+		case len(parts) == 4 && parts[1] == "stores":
+			writeSettings(w, stores[strings.ToUpper(parts[2])].timezone)
+		case len(parts) == 4 && parts[1] == "terminals":
+			writeSettings(w, terminals[parts[2]])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	logger := zap.NewNop()
+	adyenAPI := adyen.New(logger, server.Client(), "", "", host, "key", "", "", "", "")
+	config := &commands.Config{AdyenMgmtTestURL: host, AdyenMgmtTestKey: "key"}
+	return NewTimezones(adyenAPI, resolver.New(logger, adyenAPI, config, false))
+}
+
+func writeSettings(w http.ResponseWriter, timezone string) {
+	writeJSON(w, map[string]interface{}{"localization": map[string]interface{}{"timezone": timezone}})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
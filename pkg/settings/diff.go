@@ -0,0 +1,116 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Change declare one setting, which differs from the desired value.
+type Change struct {
+	Path    string
+	Current interface{}
+	Desired interface{}
+}
+
+// Format formats the current and the desired values, secrets are masked.
+func (c *Change) Format() (current, desired string) {
+	if IsSecret(c.Path[strings.LastIndex(c.Path, ".")+1:]) {
+		return masked, masked
+	}
+	return FormatValue(c.Current), FormatValue(c.Desired)
+}
+
+// Diff compares the current settings with the desired ones.
+// Only the desired paths are compared, arrays are compared as a whole, as Adyen replaces them on PATCH.
+// Changes are sorted by path.
+func Diff(current, desired map[string]interface{}) []Change {
+	var changes []Change
+	diff("", current, desired, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func diff(path string, current, desired map[string]interface{}, changes *[]Change) {
+	for key, value := range desired {
+		childPath := join(path, key)
+		currentValue, ok := current[key]
+		if desiredMap, isMap := value.(map[string]interface{}); isMap {
+			currentMap, _ := currentValue.(map[string]interface{})
+			diff(childPath, currentMap, desiredMap, changes)
+			continue
+		}
+		if !ok || !Equal(currentValue, value) {
+			*changes = append(*changes, Change{Path: childPath, Current: currentValue, Desired: value})
+		}
+	}
+}
+
+// Equal compares two values by their JSON representation, so numbers of different types are equal.
+func Equal(a, b interface{}) bool {
+	bufA, errA := json.Marshal(a)
+	bufB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(bufA, bufB)
+}
+
+// Patch builds the partial settings document from the changes, with the desired values only.
+func Patch(changes []Change) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for _, change := range changes {
+		Set(patch, change.Path, change.Desired)
+	}
+	return patch
+}
+
+// Get returns the value by the dotted path, maps are traversed only.
+func Get(document map[string]interface{}, path string) (interface{}, bool) {
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		value, ok := document[key]
+		if !ok {
+			return nil, false
+		}
+		if i == len(keys)-1 {
+			return value, true
+		}
+		if document, ok = value.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// Set sets the value by the dotted path, missing maps are created.
+func Set(document map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := document[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			document[key] = child
+		}
+		document = child
+	}
+	document[keys[len(keys)-1]] = value
+}
+
+// Merge returns the deep copy of the base document with the override applied on top.
+func Merge(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		overrideMap, isMap := value.(map[string]interface{})
+		baseMap, isBaseMap := merged[key].(map[string]interface{})
+		if isMap && isBaseMap {
+			merged[key] = Merge(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
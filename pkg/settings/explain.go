@@ -0,0 +1,81 @@
+package settings
+
+import (
+	"sort"
+	"strings"
+)
+
+// Layer declare the settings document of one level of inheritance, like company, merchant, store or terminal.
+type Layer struct {
+	Level    string
+	Settings map[string]interface{}
+}
+
+// Origin declare the effective value of one setting and the level, which defined it.
+type Origin struct {
+	Path  string
+	Value interface{}
+	Level string
+	// OverriddenLevel is the closest level above, which defines the different value, empty if nothing is overridden.
+	OverriddenLevel string
+	OverriddenValue interface{}
+}
+
+// Format formats the effective and the overridden values, secrets are masked.
+func (o *Origin) Format() (value, overridden string) {
+	if IsSecret(o.Path[strings.LastIndex(o.Path, ".")+1:]) {
+		if o.OverriddenLevel == "" {
+			return masked, ""
+		}
+		return masked, masked
+	}
+	return FormatValue(o.Value), FormatValue(o.OverriddenValue)
+}
+
+// Explain finds the level, which defined every effective setting.
+// Layers go from the top (company) to the bottom (terminal), the bottom layer contains the effective settings.
+// The value is defined on the highest level, from which it's inherited unchanged down to the bottom.
+// Layers without the value are skipped, arrays are compared as a whole.
+// Origins are sorted by path.
+func Explain(layers []Layer) []Origin {
+	if len(layers) == 0 {
+		return nil
+	}
+
+	bottom := layers[len(layers)-1]
+	var fields []Field
+	leaves("", bottom.Settings, &fields)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Path < fields[j].Path
+	})
+
+	origins := make([]Origin, 0, len(fields))
+	for _, field := range fields {
+		origin := Origin{Path: field.Path, Value: field.Value, Level: bottom.Level}
+		for i := len(layers) - 2; i >= 0; i-- {
+			value, ok := Get(layers[i].Settings, field.Path)
+			if !ok {
+				continue
+			}
+			if !Equal(value, field.Value) {
+				origin.OverriddenLevel = layers[i].Level
+				origin.OverriddenValue = value
+				break
+			}
+			origin.Level = layers[i].Level
+		}
+		origins = append(origins, origin)
+	}
+	return origins
+}
+
+// leaves collects values with dotted paths, only maps are traversed.
+func leaves(path string, value interface{}, fields *[]Field) {
+	if v, ok := value.(map[string]interface{}); ok {
+		for key, child := range v {
+			leaves(join(path, key), child, fields)
+		}
+		return
+	}
+	*fields = append(*fields, Field{Path: path, Value: value})
+}
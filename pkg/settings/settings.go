@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// masked replaces the secret values.
+const masked = "******"
+
+// secretKeys declare the key endings (lower case), which values are secrets.
+// Wi-Fi profiles keep credentials in psk, eapPwd and eapClientPwd.
+var secretKeys = []string{"password", "passphrase", "pin", "secret", "apikey", "privatekey", "psk", "pwd"}
+
+// Field declare one leaf value of the settings document.
+type Field struct {
+	Path  string
+	Value interface{}
+}
+
+// Flatten returns all leaf values with dotted paths, like storeAndForward.maxPayments, sorted by path.
+// Array elements are addressed by the index, like gratuities.0.currency.
+func Flatten(document map[string]interface{}) []Field {
+	var fields []Field
+	flatten("", document, &fields)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Path < fields[j].Path
+	})
+	return fields
+}
+
+func flatten(path string, value interface{}, fields *[]Field) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flatten(join(path, key), child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(join(path, strconv.Itoa(i)), child, fields)
+		}
+	default:
+		*fields = append(*fields, Field{Path: path, Value: v})
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Mask returns the copy of the document, where secret values are masked.
+func Mask(document map[string]interface{}) map[string]interface{} {
+	masked, _ := mask("", document).(map[string]interface{})
+	return masked
+}
+
+func mask(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for childKey, child := range v {
+			copied[childKey] = mask(childKey, child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = mask(key, child)
+		}
+		return copied
+	case nil:
+		return v
+	default:
+		// Secrets are masked whatever the value type is
+		if v != "" && IsSecret(key) {
+			return masked
+		}
+		return v
+	}
+}
+
+// IsSecret checks if the value of the key is a secret.
+// The key must end with the whole word of camel case, so adminMenuPin is the secret, but spin is not.
+func IsSecret(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if !strings.HasSuffix(lowerKey, secret) {
+			continue
+		}
+		start := len(key) - len(secret)
+		if start == 0 || unicode.IsUpper(rune(key[start])) || key[start-1] == '_' || key[start-1] == '-' {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatValue formats the leaf value for CSV and reports.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]interface{}, []interface{}:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(buf)
+	default:
+		return fmt.Sprint(v)
+	}
+}
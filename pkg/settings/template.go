@@ -0,0 +1,64 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadTemplate loads the partial settings document from JSON or YAML (.yaml, .yml) file.
+func LoadTemplate(path string) (map[string]interface{}, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	var template map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buf, &template)
+	default:
+		err = json.Unmarshal(buf, &template)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template: %w", err)
+	}
+	if template == nil {
+		template = make(map[string]interface{})
+	}
+	return template, nil
+}
+
+// ParseValue parses the value from CSV.
+// The type of the current value wins, if it's known, so "1234" stays the string for PINs.
+// Otherwise the value is parsed as JSON literal (number, bool, array, object) or kept as the string.
+func ParseValue(raw string, current interface{}) (interface{}, error) {
+	switch current.(type) {
+	case string:
+		return raw, nil
+	case bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bool: %w", err)
+		}
+		return value, nil
+	case json.Number, float64, int:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse number: %w", err)
+		}
+		return json.Number(raw), nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return raw, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,194 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// operators declare comparison operators of version ranges, longer ones go first.
+var operators = []string{">=", "<=", ">", "<", "="}
+
+// constraint declare one comparison of the version range, like `>=1.2`.
+type constraint struct {
+	operator string
+	version  string
+}
+
+// Range declare constraints, which all must match.
+type Range []constraint
+
+// IsRange checks if the version selector is the range: contains comparison operators or wildcards.
+func IsRange(version string) bool {
+	if strings.ContainsAny(version, "<>=") {
+		return true
+	}
+	parts := strings.Split(version, ".")
+	return wildcardIndex(parts) < len(parts)
+}
+
+// IsValid checks if the version starts with the number and every its part starts with the number, like 1.90.7-beta.
+func IsValid(version string) bool {
+	for _, part := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		if leadingDigits(part) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRange parses the range, constraints are separated by spaces or commas.
+func ParseRange(version string) (Range, error) {
+	var constraints Range
+	for _, field := range strings.FieldsFunc(version, func(r rune) bool { return r == ' ' || r == ',' }) {
+		parsed, err := parseConstraint(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", version, err)
+		}
+		constraints = append(constraints, parsed...)
+	}
+	return constraints, nil
+}
+
+// parseConstraint parses one constraint of the range.
+// Wildcard versions, like `1.2.x`, are converted to `>=1.2 <1.3`.
+func parseConstraint(field string) ([]constraint, error) {
+	operator := ""
+	for _, op := range operators {
+		if strings.HasPrefix(field, op) {
+			operator = op
+			break
+		}
+	}
+	value := strings.TrimPrefix(field, operator)
+	if value == "" {
+		return nil, fmt.Errorf("no version after %q", operator)
+	}
+
+	parts := strings.Split(value, ".")
+	wildcard := wildcardIndex(parts)
+	switch {
+	case wildcard == len(parts) && operator == "":
+		return []constraint{{operator: "=", version: value}}, nil
+	case wildcard == len(parts):
+		return []constraint{{operator: operator, version: value}}, nil
+	case operator != "":
+		return nil, fmt.Errorf("wildcards can't be compared")
+	case wildcard == 0:
+		// any version matches
+		return nil, nil
+	default:
+		return []constraint{
+			{operator: ">=", version: strings.Join(parts[:wildcard], ".")},
+			{operator: "<", version: nextVersion(parts[:wildcard])},
+		}, nil
+	}
+}
+
+// wildcardIndex returns the index of the first wildcard part or the number of parts, if there is no wildcard.
+func wildcardIndex(parts []string) int {
+	for i, part := range parts {
+		if isWildcard(part) {
+			return i
+		}
+	}
+	return len(parts)
+}
+
+// Match checks if the version is in the range.
+func (r Range) Match(version string) bool {
+	for _, c := range r {
+		result := Compare(version, c.version)
+		var ok bool
+		switch c.operator {
+		case ">=":
+			ok = result >= 0
+		case "<=":
+			ok = result <= 0
+		case ">":
+			ok = result > 0
+		case "<":
+			ok = result < 0
+		default:
+			ok = result == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isWildcard(part string) bool {
+	return part == "*" || part == "x" || part == "X"
+}
+
+// nextVersion increments the last part of the version: 1.2 -> 1.3.
+func nextVersion(parts []string) string {
+	next := make([]string, len(parts))
+	copy(next, parts)
+	last := len(next) - 1
+	n, _ := strconv.Atoi(leadingDigits(next[last]))
+	next[last] = strconv.Itoa(n + 1)
+	return strings.Join(next, ".")
+}
+
+// Compare compares versions part by part, numeric parts are compared as numbers.
+// Missing parts are zeros, so 1.2 equals 1.2.0.
+func Compare(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+		if result := comparePart(aPart, bPart); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// comparePart compares leading numbers first, then the rest of parts as strings: 10 > 9, 1-beta < 1.
+func comparePart(a, b string) int {
+	aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+	aNumber, _ := strconv.Atoi(aDigits)
+	bNumber, _ := strconv.Atoi(bDigits)
+	switch {
+	case aNumber != bNumber:
+		return compareInts(aNumber, bNumber)
+	case aDigits == a && bDigits == b:
+		return 0
+	case aDigits == a:
+		// release is newer than its pre-release
+		return 1
+	case bDigits == b:
+		return -1
+	default:
+		return strings.Compare(a[len(aDigits):], b[len(bDigits):])
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func leadingDigits(s string) string {
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return s[:i]
+		}
+	}
+	return s
+}
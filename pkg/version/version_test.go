@@ -0,0 +1,152 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{name: "equal", a: "1.90.7", b: "1.90.7", want: 0},
+		{name: "missing parts are zeros", a: "1.2", b: "1.2.0", want: 0},
+		{name: "numeric parts compared as numbers", a: "1.10", b: "1.9", want: 1},
+		{name: "older", a: "1.9.9", b: "1.10", want: -1},
+		{name: "v prefix", a: "v1.2.3", b: "1.2.3", want: 0},
+		{name: "release newer than pre-release", a: "1.2", b: "1.2-beta", want: 1},
+		{name: "pre-release older than release", a: "1.2-beta", b: "1.2", want: -1},
+		{name: "pre-releases compared as strings", a: "1.2-alpha", b: "1.2-beta", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(tt.a, tt.b); got != tt.want {
+				t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{version: "1.90", want: true},
+		{version: "1.90.7-beta", want: true},
+		{version: "v1.2.3", want: true},
+		{version: "abc", want: false},
+		{version: "", want: false},
+		{version: "1.x", want: false},
+		{version: "1..2", want: false},
+		{version: "1.2.", want: false},
+		{version: "beta.1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := IsValid(tt.version); got != tt.want {
+				t.Errorf("IsValid(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRange(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{version: "1.90.7", want: false},
+		{version: ">=1.90", want: true},
+		{version: "<2", want: true},
+		{version: "=1.2", want: true},
+		{version: "1.2.x", want: true},
+		{version: "1.*", want: true},
+		{version: "X", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := IsRange(tt.version); got != tt.want {
+				t.Errorf("IsRange(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		match    []string
+		mismatch []string
+	}{
+		{
+			name:     "exact",
+			version:  "1.2.3",
+			match:    []string{"1.2.3", "v1.2.3"},
+			mismatch: []string{"1.2.4", "1.2"},
+		},
+		{
+			name:     "bounds separated by space",
+			version:  ">=1.2 <2",
+			match:    []string{"1.2", "1.10", "1.99.9"},
+			mismatch: []string{"1.1.9", "2", "2.0.1"},
+		},
+		{
+			name:     "bounds separated by comma",
+			version:  ">1.2,<=1.4",
+			match:    []string{"1.2.1", "1.4"},
+			mismatch: []string{"1.2", "1.4.1"},
+		},
+		{
+			name:     "wildcard",
+			version:  "1.2.x",
+			match:    []string{"1.2", "1.2.0", "1.2.99"},
+			mismatch: []string{"1.1.9", "1.3"},
+		},
+		{
+			name:     "wildcard in the middle",
+			version:  "1.*.5",
+			match:    []string{"1.0", "1.99.1"},
+			mismatch: []string{"0.9", "2.0"},
+		},
+		{
+			name:    "any version",
+			version: "*",
+			match:   []string{"0.1", "99"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRange(tt.version)
+			if err != nil {
+				t.Fatalf("ParseRange(%q) error = %v", tt.version, err)
+			}
+			for _, v := range tt.match {
+				if !r.Match(v) {
+					t.Errorf("ParseRange(%q).Match(%q) = false, want true", tt.version, v)
+				}
+			}
+			for _, v := range tt.mismatch {
+				if r.Match(v) {
+					t.Errorf("ParseRange(%q).Match(%q) = true, want false", tt.version, v)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRangeErrors(t *testing.T) {
+	tests := []string{
+		">=",
+		"1.2 <",
+		">=1.x",
+		"<1.*",
+	}
+	for _, version := range tests {
+		t.Run(version, func(t *testing.T) {
+			if _, err := ParseRange(version); err == nil {
+				t.Errorf("ParseRange(%q) error = nil, want error", version)
+			}
+		})
+	}
+}